@@ -0,0 +1,218 @@
+/*
+archive.go contains support for reading archives (zip, jar, tar and gzipped tar
+files) as if they were directories.
+*/
+
+package dpath
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+The index of an archive: the FileInfo of each file in it (by its path within
+the archive), and the names of the files in each of its directories, in
+document order. The root of the archive has the path "".
+
+Files within an archive are FileItems whose path is the path of the archive
+followed by their path within it, like /dist/app.tar.gz/bin/app. They keep a
+pointer to the index, so that they can be navigated without reading the
+archive again. Their contents can't be read.
+*/
+type archiveIndex struct {
+	Path     string
+	Entries  map[string]os.FileInfo
+	Children map[string][]string
+}
+
+/*
+archiveDirInfo is the FileInfo of a directory which isn't in an archive itself,
+but which has files in it (many archives leave out their directories).
+*/
+type archiveDirInfo struct {
+	name string
+}
+
+func (i *archiveDirInfo) Name() string       { return i.name }
+func (i *archiveDirInfo) Size() int64        { return 0 }
+func (i *archiveDirInfo) Mode() os.FileMode  { return os.ModeDir | 0755 }
+func (i *archiveDirInfo) ModTime() time.Time { return time.Time{} }
+func (i *archiveDirInfo) IsDir() bool        { return true }
+func (i *archiveDirInfo) Sys() interface{}   { return nil }
+
+/*
+Return true if a file is an archive that should be read like a directory. This
+depends on its extension, and on the Archives option in the context. Archives
+within archives are not read.
+*/
+func isArchive(ctx *Context, file *FileItem) bool {
+	if !ctx.Archives || file.Archive != nil || file.Info.IsDir() {
+		return false
+	}
+	name := strings.ToLower(file.Info.Name())
+	for _, ext := range []string{".zip", ".jar", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Read the index of an archive.
+*/
+func readArchive(ctx *Context, file string) (*archiveIndex, error) {
+	a := &archiveIndex{
+		Path:     file,
+		Entries:  map[string]os.FileInfo{},
+		Children: map[string][]string{},
+	}
+	name := strings.ToLower(file)
+	var err error
+	if strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".jar") {
+		err = a.readZip(ctx)
+	} else {
+		err = a.readTar(ctx, strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, names := range a.Children {
+		sort.Strings(names)
+	}
+	return a, nil
+}
+
+func (a *archiveIndex) readZip(ctx *Context) error {
+	f, err := openFile(ctx, a.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ra, size, err := readerAt(f)
+	if err != nil {
+		return err
+	}
+	r, err := zip.NewReader(ra, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range r.File {
+		a.add(f.Name, f.FileInfo())
+	}
+	return nil
+}
+
+func (a *archiveIndex) readTar(ctx *Context, gzipped bool) error {
+	f, err := openFile(ctx, a.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		a.add(hdr.Name, hdr.FileInfo())
+	}
+}
+
+/*
+Add a file to the index, along with any of its directories that are missing.
+Names which would be outside of the archive (like ../x) are ignored.
+*/
+func (a *archiveIndex) add(name string, info os.FileInfo) {
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return
+	}
+	if _, ok := a.Entries[name]; !ok {
+		parent := path.Dir(name)
+		if parent == "." {
+			parent = ""
+		} else if _, ok := a.Entries[parent]; !ok {
+			a.add(parent, &archiveDirInfo{name: path.Base(parent)})
+		}
+		a.Children[parent] = append(a.Children[parent], path.Base(name))
+	}
+	a.Entries[name] = info
+}
+
+/*
+Return the file at a path within the archive, or nil if there's no such file.
+*/
+func (a *archiveIndex) file(p string) *FileItem {
+	if !strings.HasPrefix(p, a.Path+"/") {
+		return nil
+	}
+	info, ok := a.Entries[strings.TrimPrefix(p, a.Path+"/")]
+	if !ok {
+		return nil
+	}
+	return &FileItem{Path: p, Info: info, Archive: a}
+}
+
+/*
+If a file is an archive (see isArchive()) or a directory within one, return the
+files in it in document order, and true. Otherwise, return false.
+*/
+func archiveChildren(ctx *Context, dir *FileItem) ([]Item, bool) {
+	a, entry := dir.Archive, ""
+	if a != nil {
+		if !dir.Info.IsDir() {
+			return nil, false
+		}
+		entry = strings.TrimPrefix(dir.Path, a.Path+"/")
+	} else if isArchive(ctx, dir) {
+		var err error
+		if a, err = readArchive(ctx, dir.Path); err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"file":  dir.Path,
+			}).Warn("Error encountered while reading archive.")
+			return []Item{}, true
+		}
+	} else {
+		return nil, false
+	}
+	children := []Item{}
+	for _, name := range a.Children[entry] {
+		children = append(children, a.file(path.Join(dir.Path, name)))
+	}
+	return children, true
+}
+
+/*
+Return the file at a path, which may be within the same archive as another file.
+This is used to find the parents of files.
+*/
+func relatedFile(ctx *Context, file *FileItem, p string) (*FileItem, error) {
+	if file.Archive != nil {
+		if item := file.Archive.file(p); item != nil {
+			return item, nil
+		}
+	}
+	return newFileItem(ctx, p)
+}
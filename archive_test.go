@@ -0,0 +1,94 @@
+package dpath
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/*
+Create an archive for testing, containing files with the given names and
+contents. Directories aren't included, unless they're named explicitly.
+*/
+func writeTestArchive(t *testing.T, name string, files ...string) {
+	f, err := os.Create(name)
+	assert.Nil(t, err)
+	defer f.Close()
+	if filepath.Ext(name) == ".zip" {
+		w := zip.NewWriter(f)
+		for i := 0; i < len(files); i += 2 {
+			fw, err := w.Create(files[i])
+			assert.Nil(t, err)
+			_, err = fw.Write([]byte(files[i+1]))
+			assert.Nil(t, err)
+		}
+		assert.Nil(t, w.Close())
+		return
+	}
+	gz := gzip.NewWriter(f)
+	w := tar.NewWriter(gz)
+	for i := 0; i < len(files); i += 2 {
+		hdr := &tar.Header{Name: files[i], Mode: 0644, Size: int64(len(files[i+1]))}
+		assert.Nil(t, w.WriteHeader(hdr))
+		_, err := w.Write([]byte(files[i+1]))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+	assert.Nil(t, gz.Close())
+}
+
+func TestArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dpath")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	writeTestArchive(t, filepath.Join(dir, "a.zip"),
+		"META-INF/MANIFEST.MF", "x", "com/a/B.class", "yy")
+	writeTestArchive(t, filepath.Join(dir, "b.tar.gz"),
+		"./bin/app", "hi!", "README", "", "../evil", "")
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "c.tar"), []byte("junk"), 0644))
+
+	cases := []struct {
+		expr     string
+		archives bool
+		names    []string
+	}{
+		{".//*", false, []string{"a.zip", "b.tar.gz", "c.tar"}},
+		{".//*", true, []string{
+			"a.zip", "META-INF", "MANIFEST.MF", "com", "a", "B.class",
+			"b.tar.gz", "README", "bin", "app", "c.tar",
+		}},
+		{"b.tar.gz/*", true, []string{"README", "bin"}},
+		{"b.tar.gz/bin/app/@size", true, []string{"3"}},
+		{"b.tar.gz/b*", true, []string{"bin"}},
+		{"a.zip/com/a/B.class/ancestor::*[position() le 3]", true, []string{"a.zip", "com", "a"}},
+		{"a.zip//B.class/../../..", true, []string{"a.zip"}},
+		{"a.zip/com/dir()", true, []string{"a"}},
+		{"a.zip//file()", true, []string{"MANIFEST.MF", "B.class"}},
+		{"a.zip/*", false, []string{}},
+		{"c.tar/*", true, []string{}},
+		{"a.zip//B.class/line::*", true, []string{}},
+		{"a.zip//B.class[file-contains(., 'yy')]", true, []string{}},
+	}
+	for _, c := range cases {
+		tree := assertParses(t, c.expr)
+		ctx := MockDefaultContext()
+		ctx.Axes["child"] = &ChildAxis{}
+		ctx.Axes["parent"] = &ParentAxis{}
+		ctx.Axes["ancestor"] = &AncestorAxis{}
+		ctx.Axes["descendant-or-self"] = &DescendantOrSelfAxis{}
+		ctx.Axes["attribute"] = &AttributeAxis{}
+		ctx.Axes["line"] = &LineAxis{}
+		ctx.CurrentAxis = ctx.Axes["child"]
+		ctx.Archives = c.archives
+		ctx.ContextItem, err = newFileItem(ctx, dir)
+		assert.Nil(t, err)
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, c.expr)
+		assertFileNames(t, ctx, seq, c.names...)
+	}
+}
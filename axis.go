@@ -2,13 +2,16 @@
 axis.go contains data structures related to the context and axes.
 */
 
-package main
+package dpath
 
 import (
 	"errors"
-	log "github.com/Sirupsen/logrus"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io/fs"
 	"os"
 	"path"
+	"strings"
 )
 
 /*
@@ -25,12 +28,145 @@ you could just stat() the file and handle the error if it doesn't exist?
 Anyway, GetByName() returns a sequence of items from the Axis matching a name.
 Iterate() returns all the items in the axis (from the context item) in a
 sequence.
+
+Both should return their items in document order, so that query results are
+the same from one run to the next. Document order is a depth-first pre-order
+traversal of the file system, where the contents of a directory are visited in
+lexicographic order of their names (see documentOrder()).
 */
 type Axis interface {
 	GetByName(ctx *Context, name string) (Sequence, error)
 	Iterate(ctx *Context) (Sequence, error)
 }
 
+/*
+A ReverseAxis is an axis which goes "backwards" in document order from the
+context item, such as the parent and ancestor axes. It still returns its items
+in document order, but positional predicates on a step along a reverse axis
+count from the item nearest to the context item, as in XPath. For example,
+ancestor::*[1] is the parent.
+*/
+type ReverseAxis interface {
+	Axis
+	IsReverse() bool
+}
+
+/*
+Return true if the axis is a reverse axis.
+*/
+func isReverseAxis(a Axis) bool {
+	r, ok := a.(ReverseAxis)
+	return ok && r.IsReverse()
+}
+
+/*
+A ForwardAxis is an axis which may say that it doesn't only go forward in
+document order from the context item. Reverse axes are never forward, and other
+axes are forward unless they implement this interface and say otherwise, as the
+sibling axis does (since it contains files both before and after the context
+item). Knowing that an axis is forward lets path expressions return files
+before they have finished evaluating (see PathSequence).
+*/
+type ForwardAxis interface {
+	Axis
+	IsForward() bool
+}
+
+/*
+Return true if the axis only goes forward in document order.
+*/
+func isForwardAxis(a Axis) bool {
+	if isReverseAxis(a) {
+		return false
+	}
+	f, ok := a.(ForwardAxis)
+	return !ok || f.IsForward()
+}
+
+/*
+A PatternAxis is an axis which can find the items whose names match a pattern
+(in the syntax of path.Match()) without going through every item, like
+GetByName(). Axes which aren't PatternAxes are filtered instead.
+*/
+type PatternAxis interface {
+	Axis
+	GetByPattern(ctx *Context, pattern string) (Sequence, error)
+}
+
+/*
+Return the name of a file or attribute, for matching against a pattern.
+*/
+func itemName(it Item) (string, bool) {
+	switch it := it.(type) {
+	case *FileItem:
+		return it.Info.Name(), true
+	case *AttributeItem:
+		return it.Name, true
+	case *NodeItem:
+		return it.Name, true
+	}
+	return "", false
+}
+
+/*
+Return the nodes for which keep returns true, as a sequence. The child, parent,
+descendant and attribute axes work within structured documents (see
+ContentAxis) as well as on files, so they use this when the context item is a
+NodeItem.
+*/
+func nodeSequence(nodes []*NodeItem, keep func(node *NodeItem) bool) Sequence {
+	items := []Item{}
+	for _, node := range nodes {
+		if keep(node) {
+			items = append(items, node)
+		}
+	}
+	return newWrapperSequence(items)
+}
+
+/*
+Return the descendants of a node in document order.
+*/
+func nodeDescendants(node *NodeItem) []*NodeItem {
+	nodes := []*NodeItem{}
+	for _, child := range node.Children {
+		nodes = append(nodes, child)
+		nodes = append(nodes, nodeDescendants(child)...)
+	}
+	return nodes
+}
+
+/*
+Return the parent of a node, which is the file for a top-level node.
+*/
+func nodeParent(node *NodeItem) Item {
+	if node.Parent == nil {
+		return node.File
+	}
+	return node.Parent
+}
+
+func anyNode(node *NodeItem) bool { return true }
+
+/*
+Return the items in an axis whose names match a pattern. The pattern must be
+valid (see path.Match()).
+*/
+func getByPattern(ctx *Context, a Axis, pattern string) (Sequence, error) {
+	if p, ok := a.(PatternAxis); ok {
+		return p.GetByPattern(ctx, pattern)
+	}
+	seq, err := a.Iterate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newConditionFilter(seq, func(it Item) bool {
+		name, ok := itemName(it)
+		matched, _ := path.Match(pattern, name)
+		return ok && matched
+	}), nil
+}
+
 /*
 Some axes
 */
@@ -41,6 +177,44 @@ var (
 	AXIS_DESCENDANT_OR_SELF = &DescendantOrSelfAxis{}
 )
 
+/*
+Return true if a file is a symbolic link.
+*/
+func isSymlink(file *FileItem) bool {
+	return file.Info.Mode()&os.ModeSymlink != 0
+}
+
+/*
+Return the FileInfo of a directory, or nil if the file is not a directory. When
+the context says to follow symbolic links, a link to a directory counts as a
+directory, and this returns the FileInfo of the directory it links to.
+*/
+func dirInfo(ctx *Context, file *FileItem) os.FileInfo {
+	if file.Info.IsDir() {
+		return file.Info
+	}
+	if !ctx.FollowLinks || !isSymlink(file) {
+		return nil
+	}
+	info, err := stat(ctx, file.Path)
+	if err != nil || !info.IsDir() {
+		// A broken link, or a link to something else.
+		return nil
+	}
+	if _, ok := fileIdentity(info); !ok && !hasLinks(ctx) {
+		// We couldn't tell if following the link led to a cycle.
+		return nil
+	}
+	return info
+}
+
+/*
+Return true if a file is a directory (see dirInfo()).
+*/
+func isDir(ctx *Context, file *FileItem) bool {
+	return dirInfo(ctx, file) != nil
+}
+
 /*
 ChildAxis is the default axis for normal operation.
 */
@@ -52,14 +226,24 @@ func newChildAxis() *ChildAxis {
 }
 
 func (a *ChildAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	if node, ok := ctx.ContextItem.(*NodeItem); ok {
+		return nodeSequence(node.Children, func(n *NodeItem) bool {
+			return n.Name == name
+		}), nil
+	}
 	ctxItem, ok := ctx.ContextItem.(*FileItem)
 	if !ok {
 		return nil, errors.New(
 			"Attempting to use ChildAxis when context item is not a file.",
 		)
 	}
+	if children, ok := archiveChildren(ctx, ctxItem); ok {
+		return newConditionFilter(newWrapperSequence(children), func(it Item) bool {
+			return getFile(it).Info.Name() == name
+		}), nil
+	}
 	path := path.Join(ctxItem.Path, name)
-	newItem, err := newFileItem(path)
+	newItem, err := newFileItem(ctx, path)
 	if err != nil {
 		// assume file not found, and return empty sequence
 		return newEmptySequence(), nil
@@ -69,41 +253,77 @@ func (a *ChildAxis) GetByName(ctx *Context, name string) (Sequence, error) {
 }
 
 func (a *ChildAxis) Iterate(ctx *Context) (Sequence, error) {
+	if node, ok := ctx.ContextItem.(*NodeItem); ok {
+		return nodeSequence(node.Children, anyNode), nil
+	}
 	ctxItem, ok := ctx.ContextItem.(*FileItem)
 	if !ok {
 		return nil, errors.New(
 			"Attempting to use ChildAxis when context item is not a file.",
 		)
 	}
+	if children, ok := archiveChildren(ctx, ctxItem); ok {
+		return newWrapperSequence(children), nil
+	}
 	// don't bother trying with files
-	if !ctxItem.Info.IsDir() {
+	if !isDir(ctx, ctxItem) {
 		return newEmptySequence(), nil
 	}
-	f, err := os.Open(ctxItem.Path)
+	contents, err := readDir(ctx, ctxItem.Path, nil)
 	if err != nil {
-		f.Close()
 		log.WithFields(log.Fields{
 			"error": err,
 			"axis":  "ChildAxis",
-		}).Warn("Error encountered while calling Open().")
+		}).Warn("Error encountered while reading directory.")
 		return newEmptySequence(), nil
 	}
-	contents, err := f.Readdir(0)
+	children := make([]Item, 0, len(contents))
+	for _, info := range contents {
+		children = append(children, newFileItemFromInfo(info, ctxItem.Path))
+	}
+	return newWrapperSequence(children), nil
+}
+
+/*
+Only stat() the children whose names match, rather than every child.
+*/
+func (a *ChildAxis) GetByPattern(ctx *Context, pattern string) (Sequence, error) {
+	if node, ok := ctx.ContextItem.(*NodeItem); ok {
+		return nodeSequence(node.Children, func(n *NodeItem) bool {
+			matched, _ := path.Match(pattern, n.Name)
+			return matched
+		}), nil
+	}
+	ctxItem, ok := ctx.ContextItem.(*FileItem)
+	if !ok {
+		return nil, errors.New(
+			"Attempting to use ChildAxis when context item is not a file.",
+		)
+	}
+	if children, ok := archiveChildren(ctx, ctxItem); ok {
+		return newConditionFilter(newWrapperSequence(children), func(it Item) bool {
+			matched, _ := path.Match(pattern, getFile(it).Info.Name())
+			return matched
+		}), nil
+	}
+	if !isDir(ctx, ctxItem) {
+		return newEmptySequence(), nil
+	}
+	contents, err := readDir(ctx, ctxItem.Path, func(name string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	})
 	if err != nil {
-		f.Close()
 		log.WithFields(log.Fields{
 			"error": err,
 			"axis":  "ChildAxis",
-		}).Warn("Error encountered while calling Readdir().")
+		}).Warn("Error encountered while reading directory.")
 		return newEmptySequence(), nil
 	}
-
 	children := make([]Item, 0, len(contents))
 	for _, info := range contents {
 		children = append(children, newFileItemFromInfo(info, ctxItem.Path))
 	}
-	f.Close()
-
 	return newWrapperSequence(children), nil
 }
 
@@ -117,7 +337,15 @@ func newParentAxis() *ParentAxis {
 	return &ParentAxis{}
 }
 
+func (a *ParentAxis) IsReverse() bool { return true }
+
 func (a *ParentAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	if node, ok := ctx.ContextItem.(*NodeItem); ok {
+		if parentName, _ := itemName(nodeParent(node)); parentName == name {
+			return newSingletonSequence(nodeParent(node)), nil
+		}
+		return newEmptySequence(), nil
+	}
 	ctxItem, ok := ctx.ContextItem.(*FileItem)
 	if !ok {
 		return nil, errors.New(
@@ -129,7 +357,7 @@ func (a *ParentAxis) GetByName(ctx *Context, name string) (Sequence, error) {
 		// tried to access parent of root! sneaky...
 		return newEmptySequence(), nil
 	}
-	newItem, err := newFileItem(path)
+	newItem, err := relatedFile(ctx, ctxItem, path)
 	if err != nil {
 		panic("error finding parent of file node")
 	}
@@ -143,6 +371,9 @@ func (a *ParentAxis) GetByName(ctx *Context, name string) (Sequence, error) {
 }
 
 func (a *ParentAxis) Iterate(ctx *Context) (Sequence, error) {
+	if node, ok := ctx.ContextItem.(*NodeItem); ok {
+		return newSingletonSequence(nodeParent(node)), nil
+	}
 	ctxItem, ok := ctx.ContextItem.(*FileItem)
 	if !ok {
 		return nil, errors.New(
@@ -154,7 +385,7 @@ func (a *ParentAxis) Iterate(ctx *Context) (Sequence, error) {
 		// tried to access parent of root! sneaky...
 		return newEmptySequence(), nil
 	}
-	newItem, err := newFileItem(path)
+	newItem, err := relatedFile(ctx, ctxItem, path)
 	if err != nil {
 		panic("error finding parent of file node")
 	}
@@ -163,7 +394,8 @@ func (a *ParentAxis) Iterate(ctx *Context) (Sequence, error) {
 }
 
 /*
-AncestorAxis contains only the parent of a file.
+AncestorAxis contains the parent of a file, its parent, and so on up to the root
+directory.
 */
 type AncestorAxis struct {
 }
@@ -172,6 +404,8 @@ func newAncestorAxis() *AncestorAxis {
 	return &AncestorAxis{}
 }
 
+func (a *AncestorAxis) IsReverse() bool { return true }
+
 func (a *AncestorAxis) GetByName(ctx *Context, name string) (Sequence, error) {
 	seq, err := a.Iterate(ctx)
 	if err != nil {
@@ -194,17 +428,21 @@ func (a *AncestorAxis) Iterate(ctx *Context) (Sequence, error) {
 	p := ctxItem.Path
 	for path.Join(p, "..") != p {
 		p = path.Join(p, "..")
-		newItem, err := newFileItem(p)
+		newItem, err := relatedFile(ctx, ctxItem, p)
 		if err != nil {
 			panic("error finding parent of file node")
 		}
 		ancestors = append(ancestors, newItem)
 	}
+	// We found them from the nearest up, but document order starts at the root.
+	for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
+		ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+	}
 	return newWrapperSequence(ancestors), nil
 }
 
 /*
-DescendantOrSelfAxis is just the DescendantAxis but with self added in.
+AncestorOrSelfAxis is just the AncestorAxis but with self added in.
 */
 type AncestorOrSelfAxis struct {
 	*AncestorAxis
@@ -226,11 +464,298 @@ func (a *AncestorOrSelfAxis) Iterate(ctx *Context) (Sequence, error) {
 		return nil, err
 	}
 	return newConcatenateSequence(
-		newSingletonSequence(ctx.ContextItem),
 		seq,
+		newSingletonSequence(ctx.ContextItem),
 	), nil
 }
 
+/*
+Return the parent directory of a file, found on the parent axis, or nil if it is
+the root directory.
+*/
+func parentOf(ctx *Context, file *FileItem) (*FileItem, error) {
+	oldCtx := ctx.ContextItem
+	ctx.ContextItem = file
+	defer func() { ctx.ContextItem = oldCtx }()
+	seq, err := ctx.Axes["parent"].Iterate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hasNext, err := seq.Next(ctx)
+	if err != nil || !hasNext {
+		return nil, err
+	}
+	return getFile(seq.Value()), nil
+}
+
+/*
+Return the siblings of a file in document order. The siblings are the children
+of its parent (on the child and parent axes), but only those for which keep
+returns true are included, given the result of comparing the sibling's name
+with the name of the file. The root directory has no siblings.
+*/
+func siblingsOf(ctx *Context, file *FileItem, keep func(cmp int) bool) (Sequence, error) {
+	parent, err := parentOf(ctx, file)
+	if err != nil || parent == nil {
+		return newEmptySequence(), err
+	}
+	oldCtx := ctx.ContextItem
+	ctx.ContextItem = parent
+	children, err := ctx.Axes["child"].Iterate(ctx)
+	ctx.ContextItem = oldCtx
+	if err != nil {
+		return nil, err
+	}
+	name := file.Info.Name()
+	return newConditionFilter(children, func(it Item) bool {
+		return keep(strings.Compare(getFile(it).Info.Name(), name))
+	}), nil
+}
+
+/*
+Return the siblings of the context item (see siblingsOf()), as the axis with
+the given name.
+*/
+func siblings(ctx *Context, axis string, keep func(cmp int) bool) (Sequence, error) {
+	ctxItem, ok := ctx.ContextItem.(*FileItem)
+	if !ok {
+		return nil, fmt.Errorf(
+			"Attempting to use %s when context item is not a file.", axis,
+		)
+	}
+	return siblingsOf(ctx, ctxItem, keep)
+}
+
+/*
+Return the sibling of the context item with the given name, as long as keep
+returns true for it (see siblingsOf()). This looks the name up on the child
+axis, which is quicker than going through every sibling.
+*/
+func siblingByName(ctx *Context, axis string, name string, keep func(cmp int) bool) (Sequence, error) {
+	ctxItem, ok := ctx.ContextItem.(*FileItem)
+	if !ok {
+		return nil, fmt.Errorf(
+			"Attempting to use %s when context item is not a file.", axis,
+		)
+	}
+	if !keep(strings.Compare(name, ctxItem.Info.Name())) {
+		return newEmptySequence(), nil
+	}
+	parent, err := parentOf(ctx, ctxItem)
+	if err != nil || parent == nil {
+		return newEmptySequence(), err
+	}
+	oldCtx := ctx.ContextItem
+	ctx.ContextItem = parent
+	defer func() { ctx.ContextItem = oldCtx }()
+	return ctx.Axes["child"].GetByName(ctx, name)
+}
+
+func isFollowing(cmp int) bool { return cmp > 0 }
+func isPreceding(cmp int) bool { return cmp < 0 }
+func isOther(cmp int) bool     { return cmp != 0 }
+
+/*
+FollowingSiblingAxis contains the files in the same directory as the context
+item which come after it in document order.
+*/
+type FollowingSiblingAxis struct {
+}
+
+func (a *FollowingSiblingAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	return siblingByName(ctx, "FollowingSiblingAxis", name, isFollowing)
+}
+
+func (a *FollowingSiblingAxis) Iterate(ctx *Context) (Sequence, error) {
+	return siblings(ctx, "FollowingSiblingAxis", isFollowing)
+}
+
+/*
+PrecedingSiblingAxis contains the files in the same directory as the context
+item which come before it in document order. It is a reverse axis, so
+preceding-sibling::*[1] is the sibling just before the context item.
+*/
+type PrecedingSiblingAxis struct {
+}
+
+func (a *PrecedingSiblingAxis) IsReverse() bool { return true }
+
+func (a *PrecedingSiblingAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	return siblingByName(ctx, "PrecedingSiblingAxis", name, isPreceding)
+}
+
+func (a *PrecedingSiblingAxis) Iterate(ctx *Context) (Sequence, error) {
+	return siblings(ctx, "PrecedingSiblingAxis", isPreceding)
+}
+
+/*
+SiblingAxis contains every other file in the same directory as the context item,
+in document order. It is neither a forward nor a reverse axis.
+*/
+type SiblingAxis struct {
+}
+
+func (a *SiblingAxis) IsForward() bool { return false }
+
+func (a *SiblingAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	return siblingByName(ctx, "SiblingAxis", name, isOther)
+}
+
+func (a *SiblingAxis) Iterate(ctx *Context) (Sequence, error) {
+	return siblings(ctx, "SiblingAxis", isOther)
+}
+
+/*
+SelfAxis contains just the context item, so that name and kind tests can be
+applied to it, as in self::dir().
+*/
+type SelfAxis struct {
+}
+
+func (a *SelfAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	if itName, ok := itemName(ctx.ContextItem); ok && itName == name {
+		return newSingletonSequence(ctx.ContextItem), nil
+	}
+	return newEmptySequence(), nil
+}
+
+func (a *SelfAxis) Iterate(ctx *Context) (Sequence, error) {
+	return newSingletonSequence(ctx.ContextItem), nil
+}
+
+/*
+Return the siblings (chosen by keep, see siblings()) of the context item and
+each of its ancestors, one sequence for each, starting with the context item.
+*/
+func ancestorSiblings(ctx *Context, axis string, keep func(cmp int) bool) ([]Sequence, error) {
+	ctxItem, ok := ctx.ContextItem.(*FileItem)
+	if !ok {
+		return nil, fmt.Errorf(
+			"Attempting to use %s when context item is not a file.", axis,
+		)
+	}
+	levels := make([]Sequence, 0, 5)
+	for file := ctxItem; file != nil; {
+		seq, err := siblingsOf(ctx, file, keep)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, seq)
+		if file, err = parentOf(ctx, file); err != nil {
+			return nil, err
+		}
+	}
+	return levels, nil
+}
+
+/*
+FollowingAxis contains every file after the context item in document order,
+except for its descendants. That is, the following siblings of the context item
+and of each of its ancestors, along with all of their descendants.
+*/
+type FollowingAxis struct {
+}
+
+func (a *FollowingAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	seq, err := a.Iterate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newConditionFilter(seq, func(it Item) bool {
+		return getFile(it).Info.Name() == name
+	}), nil
+}
+
+func (a *FollowingAxis) Iterate(ctx *Context) (Sequence, error) {
+	levels, err := ancestorSiblings(ctx, "FollowingAxis", isFollowing)
+	if err != nil {
+		return nil, err
+	}
+	// The siblings of the root-most ancestor come last, so they go on the
+	// bottom of the stack.
+	for i, j := 0, len(levels)-1; i < j; i, j = i+1, j-1 {
+		levels[i], levels[j] = levels[j], levels[i]
+	}
+	return newDescendantSequenceFrom(levels), nil
+}
+
+/*
+PrecedingAxis contains every file before the context item in document order,
+except for its ancestors. That is, the preceding siblings of the context item
+and of each of its ancestors, along with all of their descendants. It is a
+reverse axis, so preceding::*[1] is the file just before the context item.
+*/
+type PrecedingAxis struct {
+}
+
+func (a *PrecedingAxis) IsReverse() bool { return true }
+
+func (a *PrecedingAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	seq, err := a.Iterate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newConditionFilter(seq, func(it Item) bool {
+		return getFile(it).Info.Name() == name
+	}), nil
+}
+
+func (a *PrecedingAxis) Iterate(ctx *Context) (Sequence, error) {
+	levels, err := ancestorSiblings(ctx, "PrecedingAxis", isPreceding)
+	if err != nil {
+		return nil, err
+	}
+	// The siblings of the root-most ancestor come first, so they are already
+	// on the top of the stack.
+	return newDescendantSequenceFrom(levels), nil
+}
+
+/*
+LinkTargetAxis contains the file that a symbolic link points to, with any other
+links along the way resolved, so that its path is the real location of the
+file. It is empty for broken links and for files which aren't links. Since the
+target could be anywhere, this is neither a forward nor a reverse axis.
+*/
+type LinkTargetAxis struct {
+}
+
+func (a *LinkTargetAxis) IsForward() bool { return false }
+
+func (a *LinkTargetAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	seq, err := a.Iterate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newConditionFilter(seq, func(it Item) bool {
+		return getFile(it).Info.Name() == name
+	}), nil
+}
+
+func (a *LinkTargetAxis) Iterate(ctx *Context) (Sequence, error) {
+	ctxItem, ok := ctx.ContextItem.(*FileItem)
+	if !ok {
+		return nil, errors.New(
+			"Attempting to use LinkTargetAxis when context item is not a file.",
+		)
+	}
+	if !isSymlink(ctxItem) {
+		return newEmptySequence(), nil
+	}
+	target, err := evalSymlinks(ctx, ctxItem.Path)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"axis":  "LinkTargetAxis",
+		}).Warn("Error encountered while resolving link.")
+		return newEmptySequence(), nil
+	}
+	newItem, err := newFileItem(ctx, target)
+	if err != nil {
+		return newEmptySequence(), nil
+	}
+	return newSingletonSequence(newItem), nil
+}
+
 /*
 DescendantAxis returns children and children of children. Its implementation is
 mostly found within the DescendantSequence.
@@ -239,6 +764,9 @@ type DescendantAxis struct {
 }
 
 func (a *DescendantAxis) Iterate(ctx *Context) (Sequence, error) {
+	if node, ok := ctx.ContextItem.(*NodeItem); ok {
+		return nodeSequence(nodeDescendants(node), anyNode), nil
+	}
 	source, ok := ctx.ContextItem.(*FileItem)
 	if !ok {
 		return nil, errors.New(
@@ -256,8 +784,8 @@ func (a *DescendantAxis) GetByName(ctx *Context, name string) (Sequence, error)
 	return newConditionFilter(
 		seq,
 		func(i Item) bool {
-			fi := i.(*FileItem)
-			return fi.Info.Name() == name
+			itName, _ := itemName(i)
+			return itName == name
 		},
 	), nil
 }
@@ -275,7 +803,8 @@ func (a *DescendantOrSelfAxis) GetByName(ctx *Context, name string) (Sequence, e
 		return nil, err
 	}
 	return newConditionFilter(seq, func(it Item) bool {
-		return getFile(it).Info.Name() == name
+		itName, _ := itemName(it)
+		return itName == name
 	}), nil
 }
 
@@ -291,46 +820,266 @@ func (a *DescendantOrSelfAxis) Iterate(ctx *Context) (Sequence, error) {
 }
 
 /*
-AttributeAxis gives file metadata. Unfortunately it's difficult to get this
-in a cross platform way. So the only current attribute I have is size.
+AttributeAxis gives file metadata. Size, modification time and permissions are
+available everywhere, but the rest come from the stat() system call, so they
+are only available on some platforms (see statAttribute()).
+
+Selecting an attribute by name (e.g. @size) returns its value, while iterating
+over the axis (e.g. @*) returns named AttributeItems, so that you can tell
+which value is which.
 */
 type AttributeAxis struct{}
 
+/*
+The names of all attributes, in the order they are returned by Iterate().
+*/
+var attributeNames = []string{
+	"size", "mtime", "atime", "ctime", "mode", "perm", "uid", "gid", "owner",
+	"group", "inode", "nlink", "dev", "blocks",
+}
+
+/*
+Return the value of a file's attribute, or nil if it doesn't have one by that
+name. Times are dateTimes, and perm is the octal string of the permission bits
+(like "0644").
+*/
+func fileAttribute(file *FileItem, name string) Item {
+	switch name {
+	case "size":
+		return newIntegerItem(file.Info.Size())
+	case "mtime":
+		return newDateTimeItem(file.Info.ModTime())
+	case "perm":
+		return newStringItem(fmt.Sprintf("%04o", file.Info.Mode().Perm()))
+	default:
+		return statAttribute(file, name)
+	}
+}
+
 func (a *AttributeAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	if node, ok := ctx.ContextItem.(*NodeItem); ok {
+		for _, attr := range node.Attributes {
+			if attr.Name == name {
+				return newSingletonSequence(attr.Item), nil
+			}
+		}
+		return newEmptySequence(), nil
+	}
 	source, ok := ctx.ContextItem.(*FileItem)
 	if !ok {
 		return nil, errors.New(
 			"Attempting to use AttributeAxis when context item is not a file.",
 		)
 	}
-	switch name {
-	case "size":
-		return newSingletonSequence(newIntegerItem(source.Info.Size())), nil
-	default:
-		return newEmptySequence(), nil
+	if value := fileAttribute(source, name); value != nil {
+		return newSingletonSequence(value), nil
 	}
+	return newEmptySequence(), nil
 }
 
 func (a *AttributeAxis) Iterate(ctx *Context) (Sequence, error) {
+	return a.GetByPattern(ctx, "*")
+}
+
+/*
+Like Iterate(), this returns named AttributeItems, since there may be several.
+*/
+func (a *AttributeAxis) GetByPattern(ctx *Context, pattern string) (Sequence, error) {
+	if node, ok := ctx.ContextItem.(*NodeItem); ok {
+		attrs := []Item{}
+		for _, attr := range node.Attributes {
+			if matched, _ := path.Match(pattern, attr.Name); matched {
+				attrs = append(attrs, attr)
+			}
+		}
+		return newWrapperSequence(attrs), nil
+	}
 	source, ok := ctx.ContextItem.(*FileItem)
 	if !ok {
 		return nil, errors.New(
 			"Attempting to use AttributeAxis when context item is not a file.",
 		)
 	}
-	return newSingletonSequence(newIntegerItem(source.Info.Size())), nil
+	attrs := make([]Item, 0, len(attributeNames))
+	for _, name := range attributeNames {
+		if matched, _ := path.Match(pattern, name); !matched {
+			continue
+		}
+		if value := fileAttribute(source, name); value != nil {
+			attrs = append(attrs, newAttributeItem(name, value))
+		}
+	}
+	return newWrapperSequence(attrs), nil
+}
+
+/*
+LineAxis contains the lines of a text file, as LineItems. The lines are read as
+they are needed (see LineSequence), so large files are never read into memory
+all at once. Directories have no lines, and neither do lines have names, so
+GetByName() never finds anything. Entries of archives can't be read yet, so
+they have no lines either.
+*/
+type LineAxis struct{}
+
+func (a *LineAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	return newEmptySequence(), nil
+}
+
+func (a *LineAxis) Iterate(ctx *Context) (Sequence, error) {
+	source, ok := ctx.ContextItem.(*FileItem)
+	if !ok {
+		return nil, errors.New(
+			"Attempting to use LineAxis when context item is not a file.",
+		)
+	}
+	if !hasLines(ctx, source) {
+		return newEmptySequence(), nil
+	}
+	return newLineSequence(source), nil
+}
+
+/*
+Return true if a file can be read as lines: it isn't a directory, and it isn't
+in an archive (see archiveChildren()).
+*/
+func hasLines(ctx *Context, file *FileItem) bool {
+	return file.Archive == nil && !isDir(ctx, file)
+}
+
+/*
+ContentAxis contains the top-level nodes of a structured document: the members
+of a JSON object or YAML mapping, the elements of an array or sequence, or the
+root element of an XML document (see parseDocument()). From there, the child,
+parent, descendant and attribute axes can be used to navigate the document.
+Files which aren't structured documents, or which can't be parsed, have no
+content.
+*/
+type ContentAxis struct{}
+
+func (a *ContentAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	seq, err := a.Iterate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newConditionFilter(seq, func(it Item) bool {
+		return it.(*NodeItem).Name == name
+	}), nil
+}
+
+func (a *ContentAxis) Iterate(ctx *Context) (Sequence, error) {
+	source, ok := ctx.ContextItem.(*FileItem)
+	if !ok {
+		return nil, errors.New(
+			"Attempting to use ContentAxis when context item is not a file.",
+		)
+	}
+	if isDir(ctx, source) {
+		return newEmptySequence(), nil
+	}
+	nodes, err := parseDocument(ctx, source)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"axis":  "ContentAxis",
+			"file":  source.Path,
+		}).Warn("Error encountered while parsing document.")
+		return newEmptySequence(), nil
+	}
+	return nodeSequence(nodes, anyNode), nil
 }
 
 /*
 Every DPath expression is evaluated within a context. The context contains
-information such as the current context item (usually the current directory)
-and the current axis (by default, children).
+information such as the current context item (usually the current directory),
+the current axis (by default, children), and the variables currently in scope.
+
+Within a predicate, ContextPosition is the position of the context item within
+the sequence being filtered, and ContextSize returns the length of that
+sequence. The size is a function since it may be expensive to compute, and it
+is rarely needed.
+
+When FollowLinks is true, symbolic links to directories are treated as the
+directories they point to, so the child and descendant axes look inside them.
+Likewise, when Archives is true, they look inside archives (see isArchive()).
+
+CallDepth is the number of declared functions (see FunctionDecl) whose bodies
+are being evaluated, so that a function which calls itself forever stops with
+an error rather than overflowing the stack.
+
+Files are read from FS, which is the real file system if it is nil. Paths of
+files are absolute, and they're looked up relative to the root of FS (see
+fsName()), so that any fs.FS, like fstest.MapFS, can be queried.
 */
 type Context struct {
-	ContextItem Item
-	CurrentAxis Axis
-	Namespace   map[string]Builtin
-	Axes        map[string]Axis
+	ContextItem     Item
+	ContextPosition int64
+	ContextSize     func(ctx *Context) (int64, error)
+	CurrentAxis     Axis
+	Namespace       map[string]Builtin
+	Axes            map[string]Axis
+	Variables       map[string][]Item
+	CallDepth       int
+	FollowLinks     bool
+	Archives        bool
+	FS              fs.FS
+}
+
+/*
+ContextSize for when the context item is not part of a sequence being filtered.
+*/
+func singletonContextSize(ctx *Context) (int64, error) {
+	return 1, nil
+}
+
+/*
+The axes which are in every default context, by name. RegisterAxis() adds more.
+Axes have no state of their own, so each context shares these.
+*/
+var registeredAxes = map[string]AxisInfo{
+	"child": {Name: "child", Axis: &ChildAxis{},
+		Doc: "The contents of a directory (the default axis)."},
+	"parent": {Name: "parent", Axis: &ParentAxis{},
+		Doc: "The directory containing a file."},
+	"descendant": {Name: "descendant", Axis: &DescendantAxis{},
+		Doc: "The contents of a directory, and of every directory within it."},
+	"descendant-or-self": {Name: "descendant-or-self", Axis: &DescendantOrSelfAxis{},
+		Doc: "A file, and its descendants."},
+	"ancestor": {Name: "ancestor", Axis: &AncestorAxis{},
+		Doc: "The directories containing a file, up to the root."},
+	"ancestor-or-self": {Name: "ancestor-or-self", Axis: &AncestorOrSelfAxis{},
+		Doc: "A file, and its ancestors."},
+	"following-sibling": {Name: "following-sibling", Axis: &FollowingSiblingAxis{},
+		Doc: "The files after a file in its directory."},
+	"preceding-sibling": {Name: "preceding-sibling", Axis: &PrecedingSiblingAxis{},
+		Doc: "The files before a file in its directory."},
+	"sibling": {Name: "sibling", Axis: &SiblingAxis{},
+		Doc: "The other files in a file's directory."},
+	"following": {Name: "following", Axis: &FollowingAxis{},
+		Doc: "The files after a file in document order, other than its descendants."},
+	"preceding": {Name: "preceding", Axis: &PrecedingAxis{},
+		Doc: "The files before a file in document order, other than its ancestors."},
+	"self": {Name: "self", Axis: &SelfAxis{},
+		Doc: "The context item itself."},
+	"link-target": {Name: "link-target", Axis: &LinkTargetAxis{},
+		Doc: "The file that a symbolic link points to."},
+	"line": {Name: "line", Axis: &LineAxis{},
+		Doc: "The lines of a file."},
+	"content": {Name: "content", Axis: &ContentAxis{},
+		Doc: "The top-level nodes of a JSON, YAML or XML document."},
+	"attribute": {Name: "attribute", Axis: &AttributeAxis{},
+		Doc: "The metadata of a file, like its size and modification time."},
+}
+
+/*
+Return a map of each axis's name to the axis, including the axes added with
+RegisterAxis().
+*/
+func DefaultAxes() map[string]Axis {
+	axes := make(map[string]Axis, len(registeredAxes))
+	for name, info := range registeredAxes {
+		axes[name] = info.Axis
+	}
+	return axes
 }
 
 /*
@@ -344,23 +1093,20 @@ func DefaultContext() *Context {
 	if err != nil {
 		panic("Getwd() failed!")
 	}
-	item, err := newFileItem(wd)
+	axes := DefaultAxes()
+	ctx := &Context{
+		ContextPosition: 1,
+		ContextSize:     singletonContextSize,
+		CurrentAxis:     axes["child"],
+		Namespace:       DefaultNamespace(),
+		Axes:            axes,
+		Variables:       map[string][]Item{},
+		FS:              rootFS,
+	}
+	item, err := newFileItem(ctx, wd)
 	if err != nil {
 		panic("Lstat() failed!")
 	}
-	axes := map[string]Axis{
-		"child":              &ChildAxis{},
-		"parent":             &ParentAxis{},
-		"descendant":         &DescendantAxis{},
-		"descendant-or-self": &DescendantOrSelfAxis{},
-		"ancestor":           &AncestorAxis{},
-		"ancestor-or-self":   &AncestorOrSelfAxis{},
-		"attribute":          &AttributeAxis{},
-	}
-	return &Context{
-		ContextItem: item,
-		CurrentAxis: axes["child"],
-		Namespace:   DefaultNamespace(),
-		Axes:        axes,
-	}
+	ctx.ContextItem = item
+	return ctx
 }
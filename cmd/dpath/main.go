@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/brenns10/dpath"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+/*
+A command-line driver for evaluating DPath expressions. The work is done by the
+dpath package; this only parses the command line and prints the results.
+*/
+func main() {
+	var parseTreeBuf bytes.Buffer
+
+	followLinks := flag.Bool("L", false, "follow symbolic links to directories")
+	archives := flag.Bool("archives", false, "look inside zip, jar and tar files")
+	functions := flag.Bool("functions", false, "list the functions and axes, and exit")
+	queryFile := flag.String("f", "", "read the expression from a file (- for stdin)")
+	var vars, varFiles stringList
+	flag.Var(&vars, "var", "set $name, given as `name[:type]=value` (may be repeated)")
+	flag.Var(&varFiles, "var-file", "read -var definitions from a `file`, one per line (may be repeated)")
+	flag.Parse()
+	if *functions {
+		printFunctions()
+		return
+	}
+
+	// Parse the DPath expression.
+	expr, err := readExpression(*queryFile)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Fatal("Could not read the DPath expression.")
+	}
+	query, err := dpath.Compile(expr)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Fatal("Syntax error.")
+	}
+
+	// Log the parse tree.
+	parseTreeBuf.WriteString("Parse Tree:\n")
+	query.Tree.Print(&parseTreeBuf, 0)
+	log.WithFields(log.Fields{
+		"tree": parseTreeBuf.String(),
+	}).Debug("Created parse tree.")
+
+	// Evaluate the expression and print the results.
+	ctx := dpath.DefaultContext()
+	ctx.FollowLinks = *followLinks
+	ctx.Archives = *archives
+	if err = bindVariables(ctx, varFiles, vars); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Fatal("Could not bind variables.")
+	}
+	results, err := query.Eval(ctx, nil)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Fatal("Error while evaluating expression.")
+	}
+
+	for results.Next() {
+		if err = results.Item().Print(os.Stdout); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = results.Err()
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Fatal("Error while iterating.")
+	}
+}
+
+/*
+Return the DPath expression to evaluate: the contents of the query file if there
+is one (or stdin, when it's "-"), and otherwise the first argument.
+*/
+func readExpression(queryFile string) (string, error) {
+	if queryFile == "" {
+		if flag.NArg() < 1 {
+			return "", errors.New("must provide a DPath expression or -f")
+		}
+		return flag.Arg(0), nil
+	}
+	if flag.NArg() > 0 {
+		return "", errors.New("can't give an expression along with -f")
+	}
+	var contents []byte
+	var err error
+	if queryFile == "-" {
+		contents, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		contents, err = ioutil.ReadFile(queryFile)
+	}
+	return string(contents), err
+}
+
+/*
+A flag which may be given more than once, keeping every value.
+*/
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+/*
+Bind the variables defined in each variable file, and then the ones given with
+-var, so that those can override the files. Variable files have a definition on
+each line, and may have blank lines and comments starting with #.
+*/
+func bindVariables(ctx *dpath.Context, varFiles, vars []string) error {
+	for _, varFile := range varFiles {
+		contents, err := ioutil.ReadFile(varFile)
+		if err != nil {
+			return err
+		}
+		for i, line := range strings.Split(string(contents), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if err = bindVariable(ctx, line); err != nil {
+				return fmt.Errorf("%s:%d: %v", varFile, i+1, err)
+			}
+		}
+	}
+	for _, definition := range vars {
+		if err := bindVariable(ctx, definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Bind a variable from a definition like "name=value" or "name:type=value".
+*/
+func bindVariable(ctx *dpath.Context, definition string) error {
+	eq := strings.Index(definition, "=")
+	if eq < 0 {
+		return fmt.Errorf("expected name=value, got %q", definition)
+	}
+	name, typeName := definition[:eq], ""
+	if colon := strings.Index(name, ":"); colon >= 0 {
+		name, typeName = name[:colon], name[colon+1:]
+	}
+	return dpath.BindVariable(ctx, name, typeName, definition[eq+1:])
+}
+
+/*
+Print the signature and documentation of each function and axis.
+*/
+func printFunctions() {
+	fmt.Println("Functions:")
+	for _, builtin := range dpath.Builtins() {
+		fmt.Printf("  %s\n      %s\n", builtin.Signature(), builtin.Doc)
+	}
+	fmt.Println("Axes:")
+	for _, axis := range dpath.Axes() {
+		fmt.Printf("  %s::\n      %s\n", axis.Name, axis.Doc)
+	}
+}
+
+func init() {
+	// Log as JSON instead of the default ASCII formatter.
+	log.SetFormatter(&log.TextFormatter{})
+
+	// Output to stderr instead of stdout, could also be a file.
+	log.SetOutput(os.Stderr)
+
+	// Only log the warning severity or above.
+	log.SetLevel(log.ErrorLevel)
+}
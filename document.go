@@ -0,0 +1,278 @@
+/*
+document.go contains parsers for structured documents (JSON, YAML and XML
+files), which turn them into trees of NodeItems for the content axis.
+*/
+
+package dpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+/*
+Return the top-level nodes of a structured document, or nil if the file isn't a
+kind of document that we know how to parse, judging by its extension.
+*/
+func parseDocument(ctx *Context, file *FileItem) ([]*NodeItem, error) {
+	var parse func(r io.Reader) ([]*NodeItem, error)
+	switch strings.ToLower(path.Ext(file.Path)) {
+	case ".json":
+		parse = parseJSON
+	case ".yaml", ".yml":
+		parse = parseYAML
+	case ".xml":
+		parse = parseXML
+	default:
+		return nil, nil
+	}
+	f, err := openFile(ctx, file.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	nodes, err := parse(f)
+	if err != nil {
+		return nil, err
+	}
+	linkNodes(nodes, file, nil)
+	return nodes, nil
+}
+
+/*
+Fill in the file, parent and position of each node in a tree.
+*/
+func linkNodes(nodes []*NodeItem, file *FileItem, parent *NodeItem) {
+	for i, node := range nodes {
+		node.File = file
+		node.Parent = parent
+		node.Position = i + 1
+		linkNodes(node.Children, file, node)
+	}
+}
+
+/*
+Parse a JSON document. The top-level nodes are the members of an object, or the
+elements of an array. Numbers are integers when they can be, and null is an
+empty string.
+*/
+func parseJSON(r io.Reader) ([]*NodeItem, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	root, container, err := readJSON(dec, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid JSON: more than one value")
+	}
+	if container {
+		return root.Children, nil
+	}
+	return []*NodeItem{root}, nil
+}
+
+/*
+Read a JSON value (which is named name) from the decoder, returning its node,
+and whether it is an object or array.
+*/
+func readJSON(dec *json.Decoder, name string) (*NodeItem, bool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, false, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		children := []*NodeItem{}
+		for dec.More() {
+			childName := ""
+			if tok == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, false, err
+				}
+				childName = key.(string)
+			}
+			child, _, err := readJSON(dec, childName)
+			if err != nil {
+				return nil, false, err
+			}
+			children = append(children, child)
+		}
+		// Read the closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return nil, false, err
+		}
+		return newContainerNode(name, children), true, nil
+	case json.Number:
+		if i, err := tok.Int64(); err == nil {
+			return newScalarNode(name, newIntegerItem(i)), false, nil
+		}
+		f, err := tok.Float64()
+		if err != nil {
+			return nil, false, err
+		}
+		return newScalarNode(name, newDoubleItem(f)), false, nil
+	case string:
+		return newScalarNode(name, newStringItem(tok)), false, nil
+	case bool:
+		return newScalarNode(name, newBooleanItem(tok)), false, nil
+	default:
+		return newScalarNode(name, newStringItem("")), false, nil
+	}
+}
+
+/*
+Parse a YAML file. Like JSON, the top-level nodes are the members of a mapping,
+or the elements of a sequence. When there are several documents in the file,
+their top-level nodes are all put together.
+*/
+func parseYAML(r io.Reader) ([]*NodeItem, error) {
+	dec := yaml.NewDecoder(r)
+	nodes := []*NodeItem{}
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			return nodes, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		expanded := 0
+		root, container, err := convertYAML(doc.Content[0], "", nil, &expanded)
+		if err != nil {
+			return nil, err
+		}
+		if container {
+			nodes = append(nodes, root.Children...)
+		} else {
+			nodes = append(nodes, root)
+		}
+	}
+}
+
+/*
+The most nodes that aliases in a YAML document may expand to. Each use of an
+alias copies the nodes it refers to, so a small file with aliases of aliases
+(a "billion laughs") could otherwise expand to more nodes than fit in memory.
+*/
+const maxYAMLAliasNodes = 100000
+
+/*
+Convert a YAML node (which is named name) into a NodeItem, returning whether it
+is a mapping or sequence. Aliases are replaced by the nodes they refer to, except
+within those same nodes, since that would never end. The number of nodes made
+from aliases so far is counted in expanded, and it is an error for there to be
+more than maxYAMLAliasNodes.
+*/
+func convertYAML(y *yaml.Node, name string, aliases []*yaml.Node, expanded *int) (*NodeItem, bool, error) {
+	if len(aliases) > 0 {
+		if *expanded++; *expanded > maxYAMLAliasNodes {
+			return nil, false, fmt.Errorf("YAML aliases expand to more than %d nodes", maxYAMLAliasNodes)
+		}
+	}
+	switch y.Kind {
+	case yaml.AliasNode:
+		for _, a := range aliases {
+			if a == y.Alias {
+				return newScalarNode(name, newStringItem("")), false, nil
+			}
+		}
+		return convertYAML(y.Alias, name, append(aliases, y.Alias), expanded)
+	case yaml.MappingNode, yaml.SequenceNode:
+		children := []*NodeItem{}
+		for i := 0; i < len(y.Content); i++ {
+			childName := ""
+			if y.Kind == yaml.MappingNode {
+				childName = y.Content[i].Value
+				i++
+			}
+			child, _, err := convertYAML(y.Content[i], childName, aliases, expanded)
+			if err != nil {
+				return nil, false, err
+			}
+			children = append(children, child)
+		}
+		return newContainerNode(name, children), true, nil
+	}
+	switch y.ShortTag() {
+	case "!!int":
+		if i, err := strconv.ParseInt(y.Value, 0, 64); err == nil {
+			return newScalarNode(name, newIntegerItem(i)), false, nil
+		}
+	case "!!float":
+		if f, err := strconv.ParseFloat(y.Value, 64); err == nil {
+			return newScalarNode(name, newDoubleItem(f)), false, nil
+		}
+	case "!!bool":
+		if b, err := strconv.ParseBool(y.Value); err == nil {
+			return newScalarNode(name, newBooleanItem(b)), false, nil
+		}
+	case "!!null":
+		return newScalarNode(name, newStringItem("")), false, nil
+	}
+	return newScalarNode(name, newStringItem(y.Value)), false, nil
+}
+
+/*
+Parse an XML document. The top-level node is the root element. Elements have
+their attributes, and their value is the text within them (including the text
+of their descendants), with leading and trailing space removed.
+*/
+func parseXML(r io.Reader) ([]*NodeItem, error) {
+	dec := xml.NewDecoder(r)
+	type element struct {
+		Name       string
+		Attributes []*AttributeItem
+		Children   []*NodeItem
+		Text       bytes.Buffer
+	}
+	stack := []*element{{}}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		top := stack[len(stack)-1]
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			elem := &element{Name: tok.Name.Local}
+			for _, attr := range tok.Attr {
+				elem.Attributes = append(elem.Attributes,
+					newAttributeItem(attr.Name.Local, newStringItem(attr.Value)))
+			}
+			stack = append(stack, elem)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			text := top.Text.String()
+			node := &NodeItem{
+				Item:       newStringItem(strings.TrimSpace(text)),
+				Name:       top.Name,
+				Children:   top.Children,
+				Attributes: top.Attributes,
+			}
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+			parent.Text.WriteString(text)
+		case xml.CharData:
+			top.Text.Write(tok)
+		}
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("invalid XML: %d unclosed elements", len(stack)-1)
+	}
+	return stack[0].Children, nil
+}
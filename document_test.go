@@ -0,0 +1,140 @@
+package dpath
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+Assert that a document parses into nodes with the given paths and values (or
+just paths, for nodes with children), in document order.
+*/
+func assertNodes(t *testing.T, parse func(r io.Reader) ([]*NodeItem, error), doc string, expected ...string) {
+	nodes, err := parse(strings.NewReader(doc))
+	assert.Nil(t, err, doc)
+	file := MockFile("/doc", "doc", false)
+	linkNodes(nodes, file, nil)
+	actual := []string{}
+	var visit func(nodes []*NodeItem)
+	visit = func(nodes []*NodeItem) {
+		for _, node := range nodes {
+			str := node.NodePath()
+			if len(node.Children) == 0 {
+				str += "=" + node.Item.TypeName() + ":" + node.ToString()
+			}
+			actual = append(actual, str)
+			visit(node.Children)
+		}
+	}
+	visit(nodes)
+	assert.Equal(t, expected, actual, doc)
+}
+
+func TestParseJSON(t *testing.T) {
+	assertNodes(t, parseJSON,
+		`{"b": 1, "a": [2.5, true, null], "c": {"d": "e"}}`,
+		"/b=integer:1", "/a", "/a/*[1]=double:2.5", "/a/*[2]=boolean:true",
+		"/a/*[3]=string:", "/c", "/c/d=string:e",
+	)
+	assertNodes(t, parseJSON, `["x", {}]`, "/*[1]=string:x", "/*[2]=string:")
+	assertNodes(t, parseJSON, `"x"`, "/*[1]=string:x")
+	for _, doc := range []string{`{"a": }`, `{"a": 1} {}`, `[1, 2`} {
+		_, err := parseJSON(strings.NewReader(doc))
+		assert.Error(t, err, doc)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	assertNodes(t, parseYAML,
+		"kind: Deployment\nspec:\n  replicas: 0x10\n  ports: [80, 1.5]\n  debug: no\n",
+		"/kind=string:Deployment", "/spec", "/spec/replicas=integer:16",
+		"/spec/ports", "/spec/ports/*[1]=integer:80", "/spec/ports/*[2]=double:1.5",
+		"/spec/debug=string:no",
+	)
+	assertNodes(t, parseYAML, "a: &x {b: true}\nc: *x\n---\nd: ~\n",
+		"/a", "/a/b=boolean:true", "/c", "/c/b=boolean:true", "/d=string:")
+	_, err := parseYAML(strings.NewReader("a: [1, 2"))
+	assert.Error(t, err)
+
+	// Each level of aliases multiplies the nodes by ten, which is too many.
+	laughs := "a: &a [x, x, x, x, x, x, x, x, x, x]\n"
+	for c := 'b'; c <= 'i'; c++ {
+		p := string(c - 1)
+		laughs += fmt.Sprintf("%c: &%c [*%s, *%s, *%s, *%s, *%s, *%s, *%s, *%s, *%s, *%s]\n",
+			c, c, p, p, p, p, p, p, p, p, p, p)
+	}
+	_, err = parseYAML(strings.NewReader(laughs))
+	assert.Error(t, err)
+}
+
+func TestParseXML(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<project id="p">
+  <version>1.0</version>
+  <deps><dep scope="test">junit</dep><dep>guava</dep></deps>
+</project>`
+	assertNodes(t, parseXML, doc,
+		"/project", "/project/version=string:1.0", "/project/deps",
+		"/project/deps/dep=string:junit", "/project/deps/dep=string:guava",
+	)
+	nodes, err := parseXML(strings.NewReader(doc))
+	assert.Nil(t, err)
+	assert.Equal(t, "1.0\\n  junitguava", strings.Replace(nodes[0].ToString(), "\n", "\\n", -1))
+	assert.Equal(t, []*AttributeItem{newAttributeItem("id", newStringItem("p"))}, nodes[0].Attributes)
+	_, err = parseXML(strings.NewReader("<a><b></a>"))
+	assert.Error(t, err)
+}
+
+func TestContentAxis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dpath")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"package.json": `{"version": "1.2.0", "dependencies": {"lodash": "^4", "@types/node": "20"}}`,
+		"pom.xml":      `<project><deps><dep scope="test">junit</dep><dep>guava</dep></deps></project>`,
+		"k8s.YML":      "kind: Deployment\nspec: {replicas: 3}\n",
+		"bad.json":     "{",
+		"notes.txt":    "{}",
+	}
+	for name, contents := range files {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+
+	cases := map[string][]string{
+		"package.json/content::dependencies/*":                 {"^4", "20"},
+		"package.json/content::dependencies/#'@types/node'":    {"20"},
+		"package.json/content::dependencies/*/name()":          {"lodash", "@types/node"},
+		"package.json/content::version = '1.2.0'":              {"true"},
+		"pom.xml/content::project//dep[@scope = 'test']":       {"junit"},
+		"pom.xml/content::*/descendant::*[not(@*)]/name()":     {"deps", "dep"},
+		"pom.xml//dep/@*/name()":                               {},
+		"k8s.YML/content::spec/replicas + 1":                   {"4"},
+		"k8s.YML/content::sp*/../content::kind":                {"Deployment"},
+		"k8s.YML/content::kind/..":                             {"k8s.YML"},
+		"*[content::dependencies]":                             {"package.json"},
+		"count((bad.json, notes.txt)/content::*)":              {"0"},
+		"pom.xml/content::project/descendant-or-self::deps/..": {"junitguava"},
+	}
+	for uut, expected := range cases {
+		tree := assertParses(t, uut)
+		ctx := MockDefaultContext()
+		ctx.Axes["child"] = &ChildAxis{}
+		ctx.Axes["parent"] = &ParentAxis{}
+		ctx.Axes["descendant"] = &DescendantAxis{}
+		ctx.Axes["descendant-or-self"] = &DescendantOrSelfAxis{}
+		ctx.Axes["attribute"] = &AttributeAxis{}
+		ctx.Axes["content"] = &ContentAxis{}
+		ctx.CurrentAxis = ctx.Axes["child"]
+		ctx.ContextItem, err = newFileItem(ctx, dir)
+		assert.Nil(t, err)
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, uut)
+		assertFileNames(t, ctx, seq, expected...)
+	}
+}
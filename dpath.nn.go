@@ -1,4 +1,4 @@
-package main
+package dpath
 
 import (
 	"errors"
@@ -496,6 +496,300 @@ func NewLexerWithInit(in io.Reader, initFun func(*Lexer)) *Lexer {
 			},
 		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1}, nil},
 
+		// ([0-9]+|\.[0-9]+|[0-9]+\.[0-9]*)(B|[kKMGTPE]i?B?)
+		{[]bool{false, false, false, false, true, true, true, true, false, false}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 46:
+					return 1
+				case 66:
+					return -1
+				case 69:
+					return -1
+				case 71:
+					return -1
+				case 75:
+					return -1
+				case 77:
+					return -1
+				case 80:
+					return -1
+				case 84:
+					return -1
+				case 105:
+					return -1
+				case 107:
+					return -1
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 2
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 46:
+					return -1
+				case 66:
+					return -1
+				case 69:
+					return -1
+				case 71:
+					return -1
+				case 75:
+					return -1
+				case 77:
+					return -1
+				case 80:
+					return -1
+				case 84:
+					return -1
+				case 105:
+					return -1
+				case 107:
+					return -1
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 9
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 46:
+					return 3
+				case 66:
+					return 4
+				case 69:
+					return 5
+				case 71:
+					return 5
+				case 75:
+					return 5
+				case 77:
+					return 5
+				case 80:
+					return 5
+				case 84:
+					return 5
+				case 105:
+					return -1
+				case 107:
+					return 5
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 2
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 46:
+					return -1
+				case 66:
+					return 4
+				case 69:
+					return 5
+				case 71:
+					return 5
+				case 75:
+					return 5
+				case 77:
+					return 5
+				case 80:
+					return 5
+				case 84:
+					return 5
+				case 105:
+					return -1
+				case 107:
+					return 5
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 8
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 46:
+					return -1
+				case 66:
+					return -1
+				case 69:
+					return -1
+				case 71:
+					return -1
+				case 75:
+					return -1
+				case 77:
+					return -1
+				case 80:
+					return -1
+				case 84:
+					return -1
+				case 105:
+					return -1
+				case 107:
+					return -1
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 46:
+					return -1
+				case 66:
+					return 6
+				case 69:
+					return -1
+				case 71:
+					return -1
+				case 75:
+					return -1
+				case 77:
+					return -1
+				case 80:
+					return -1
+				case 84:
+					return -1
+				case 105:
+					return 7
+				case 107:
+					return -1
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 46:
+					return -1
+				case 66:
+					return -1
+				case 69:
+					return -1
+				case 71:
+					return -1
+				case 75:
+					return -1
+				case 77:
+					return -1
+				case 80:
+					return -1
+				case 84:
+					return -1
+				case 105:
+					return -1
+				case 107:
+					return -1
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 46:
+					return -1
+				case 66:
+					return 6
+				case 69:
+					return -1
+				case 71:
+					return -1
+				case 75:
+					return -1
+				case 77:
+					return -1
+				case 80:
+					return -1
+				case 84:
+					return -1
+				case 105:
+					return -1
+				case 107:
+					return -1
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 46:
+					return -1
+				case 66:
+					return 4
+				case 69:
+					return 5
+				case 71:
+					return 5
+				case 75:
+					return 5
+				case 77:
+					return 5
+				case 80:
+					return 5
+				case 84:
+					return 5
+				case 105:
+					return -1
+				case 107:
+					return 5
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 8
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 46:
+					return -1
+				case 66:
+					return 4
+				case 69:
+					return 5
+				case 71:
+					return 5
+				case 75:
+					return 5
+				case 77:
+					return 5
+				case 80:
+					return 5
+				case 84:
+					return 5
+				case 105:
+					return -1
+				case 107:
+					return 5
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 9
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, nil},
+
 		// or
 		{[]bool{false, false, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
@@ -1033,95 +1327,3958 @@ func NewLexerWithInit(in io.Reader, initFun func(*Lexer)) *Lexer {
 			},
 		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1}, nil},
 
-		// to
-		{[]bool{false, false, true}, []func(rune) int{ // Transitions
+		// symlink
+		{[]bool{false, false, false, false, false, false, false, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
-				case 111:
+				case 105:
 					return -1
-				case 116:
+				case 107:
+					return -1
+				case 108:
+					return -1
+				case 109:
+					return -1
+				case 110:
+					return -1
+				case 115:
 					return 1
+				case 121:
+					return -1
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 111:
-					return 2
-				case 116:
+				case 105:
+					return -1
+				case 107:
+					return -1
+				case 108:
+					return -1
+				case 109:
+					return -1
+				case 110:
+					return -1
+				case 115:
 					return -1
+				case 121:
+					return 2
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 111:
+				case 105:
 					return -1
-				case 116:
+				case 107:
+					return -1
+				case 108:
+					return -1
+				case 109:
+					return 3
+				case 110:
+					return -1
+				case 115:
+					return -1
+				case 121:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return -1
+				case 107:
+					return -1
+				case 108:
+					return 4
+				case 109:
+					return -1
+				case 110:
+					return -1
+				case 115:
+					return -1
+				case 121:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return 5
+				case 107:
+					return -1
+				case 108:
+					return -1
+				case 109:
+					return -1
+				case 110:
+					return -1
+				case 115:
+					return -1
+				case 121:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return -1
+				case 107:
+					return -1
+				case 108:
+					return -1
+				case 109:
+					return -1
+				case 110:
+					return 6
+				case 115:
+					return -1
+				case 121:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return -1
+				case 107:
+					return 7
+				case 108:
+					return -1
+				case 109:
+					return -1
+				case 110:
+					return -1
+				case 115:
+					return -1
+				case 121:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return -1
+				case 107:
+					return -1
+				case 108:
+					return -1
+				case 109:
+					return -1
+				case 110:
+					return -1
+				case 115:
+					return -1
+				case 121:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1}, nil},
+
+		// to
+		{[]bool{false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 111:
+					return -1
+				case 116:
+					return 1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 111:
+					return 2
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 111:
+					return -1
+				case 116:
 					return -1
 				}
 				return -1
 			},
 		}, []int{ /* Start-of-input transitions */ -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1}, nil},
 
-		// ::
+		// let
+		{[]bool{false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 108:
+					return 1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return 2
+				case 108:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 108:
+					return -1
+				case 116:
+					return 3
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 108:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1}, nil},
+
+		// for
+		{[]bool{false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 102:
+					return 1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 102:
+					return -1
+				case 111:
+					return 2
+				case 114:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 102:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return 3
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 102:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1}, nil},
+
+		// in
+		{[]bool{false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 105:
+					return 1
+				case 110:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return -1
+				case 110:
+					return 2
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return -1
+				case 110:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1}, nil},
+
+		// if
 		{[]bool{false, false, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
-				case 58:
-					return 1
+				case 102:
+					return -1
+				case 105:
+					return 1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 102:
+					return 2
+				case 105:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 102:
+					return -1
+				case 105:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1}, nil},
+
+		// then
+		{[]bool{false, false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 104:
+					return -1
+				case 110:
+					return -1
+				case 116:
+					return 1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 104:
+					return 2
+				case 110:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return 3
+				case 104:
+					return -1
+				case 110:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 104:
+					return -1
+				case 110:
+					return 4
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 104:
+					return -1
+				case 110:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1}, nil},
+
+		// else
+		{[]bool{false, false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 101:
+					return 1
+				case 108:
+					return -1
+				case 115:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 108:
+					return 2
+				case 115:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 108:
+					return -1
+				case 115:
+					return 3
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return 4
+				case 108:
+					return -1
+				case 115:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 108:
+					return -1
+				case 115:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1}, nil},
+
+		// some
+		{[]bool{false, false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 109:
+					return -1
+				case 111:
+					return -1
+				case 115:
+					return 1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 109:
+					return -1
+				case 111:
+					return 2
+				case 115:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 109:
+					return 3
+				case 111:
+					return -1
+				case 115:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return 4
+				case 109:
+					return -1
+				case 111:
+					return -1
+				case 115:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 109:
+					return -1
+				case 111:
+					return -1
+				case 115:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1}, nil},
+
+		// every
+		{[]bool{false, false, false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 101:
+					return 1
+				case 114:
+					return -1
+				case 118:
+					return -1
+				case 121:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 114:
+					return -1
+				case 118:
+					return 2
+				case 121:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return 3
+				case 114:
+					return -1
+				case 118:
+					return -1
+				case 121:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 114:
+					return 4
+				case 118:
+					return -1
+				case 121:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 114:
+					return -1
+				case 118:
+					return -1
+				case 121:
+					return 5
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 114:
+					return -1
+				case 118:
+					return -1
+				case 121:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1}, nil},
+
+		// satisfies
+		{[]bool{false, false, false, false, false, false, false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 97:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 115:
+					return 1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 97:
+					return 2
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 97:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return 3
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 97:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return 4
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 97:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 115:
+					return 5
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 97:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return 6
+				case 105:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 97:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return 7
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 97:
+					return -1
+				case 101:
+					return 8
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 97:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 115:
+					return 9
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 97:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, nil},
+
+		// union
+		{[]bool{false, false, false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 105:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 117:
+					return 1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return -1
+				case 110:
+					return 2
+				case 111:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return 3
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return 4
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return -1
+				case 110:
+					return 5
+				case 111:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 105:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1}, nil},
+
+		// intersect
+		{[]bool{false, false, false, false, false, false, false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return -1
+				case 105:
+					return 1
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return -1
+				case 105:
+					return -1
+				case 110:
+					return 2
+				case 114:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return -1
+				case 105:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return 3
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return 4
+				case 105:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return -1
+				case 105:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return 5
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return -1
+				case 105:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 115:
+					return 6
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return 7
+				case 105:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return 8
+				case 101:
+					return -1
+				case 105:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return -1
+				case 105:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return 9
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return -1
+				case 105:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 115:
+					return -1
+				case 116:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, nil},
+
+		// except
+		{[]bool{false, false, false, false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return 1
+				case 112:
+					return -1
+				case 116:
+					return -1
+				case 120:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return -1
+				case 112:
+					return -1
+				case 116:
+					return -1
+				case 120:
+					return 2
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return 3
+				case 101:
+					return -1
+				case 112:
+					return -1
+				case 116:
+					return -1
+				case 120:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return 4
+				case 112:
+					return -1
+				case 116:
+					return -1
+				case 120:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return -1
+				case 112:
+					return 5
+				case 116:
+					return -1
+				case 120:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return -1
+				case 112:
+					return -1
+				case 116:
+					return 6
+				case 120:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 99:
+					return -1
+				case 101:
+					return -1
+				case 112:
+					return -1
+				case 116:
+					return -1
+				case 120:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1, -1}, nil},
+
+		// return
+		{[]bool{false, false, false, false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return 1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return 2
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return 3
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return 4
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return 5
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 110:
+					return 6
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 101:
+					return -1
+				case 110:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1, -1}, nil},
+
+		// declare[ \t\r\n]+function
+		{[]bool{false, false, false, false, false, false, false, false, false, false, false, false, false, false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return 1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return 2
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return 3
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return 4
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return 5
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return 6
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return 7
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return 8
+				case 10:
+					return 8
+				case 13:
+					return 8
+				case 32:
+					return 8
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return 8
+				case 10:
+					return 8
+				case 13:
+					return 8
+				case 32:
+					return 8
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return 9
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return 10
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return 11
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return 12
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return 13
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return 14
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return 15
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return 16
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return -1
+				case 10:
+					return -1
+				case 13:
+					return -1
+				case 32:
+					return -1
+				case 97:
+					return -1
+				case 99:
+					return -1
+				case 100:
+					return -1
+				case 101:
+					return -1
+				case 102:
+					return -1
+				case 105:
+					return -1
+				case 108:
+					return -1
+				case 110:
+					return -1
+				case 111:
+					return -1
+				case 114:
+					return -1
+				case 116:
+					return -1
+				case 117:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, nil},
+
+		// ::
+		{[]bool{false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 58:
+					return 1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 58:
+					return 2
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 58:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1}, nil},
+
+		// :=
+		{[]bool{false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 58:
+					return 1
+				case 61:
+					return -1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 58:
+					return -1
+				case 61:
+					return 2
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 58:
+					return -1
+				case 61:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1}, nil},
+
+		// \.?[a-zA-Z_]([a-zA-Z0-9_.*?-]|(\[[a-zA-Z0-9_.!^-]*[a-zA-Z_.!^-][a-zA-Z0-9_.!^-]*\])+[a-zA-Z0-9_.*?-])*([*?]|(\[[a-zA-Z0-9_.!^-]*[a-zA-Z_.!^-][a-zA-Z0-9_.!^-]*\])+[a-zA-Z0-9_.*?-])([a-zA-Z0-9_.*?-]|(\[[a-zA-Z0-9_.!^-]*[a-zA-Z_.!^-][a-zA-Z0-9_.!^-]*\])+[a-zA-Z0-9_.*?-])*|\.[*?]([a-zA-Z0-9_.*?-]|(\[[a-zA-Z0-9_.!^-]*[a-zA-Z_.!^-][a-zA-Z0-9_.!^-]*\])+[a-zA-Z0-9_.*?-])*|[*?][*?]*([a-zA-Z0-9_.-]|(\[[a-zA-Z0-9_.!^-]*[a-zA-Z_.!^-][a-zA-Z0-9_.!^-]*\])+[a-zA-Z0-9_.*?-])([a-zA-Z0-9_.*?-]|(\[[a-zA-Z0-9_.!^-]*[a-zA-Z_.!^-][a-zA-Z0-9_.!^-]*\])+[a-zA-Z0-9_.*?-])*|(\[[a-zA-Z0-9_.!^-]*[a-zA-Z_.!^-][a-zA-Z0-9_.!^-]*\])+[a-zA-Z0-9_.*?-]([a-zA-Z0-9_.*?-]|(\[[a-zA-Z0-9_.!^-]*[a-zA-Z_.!^-][a-zA-Z0-9_.!^-]*\])+[a-zA-Z0-9_.*?-])*
+		{[]bool{false, false, false, false, false, true, false, false, false, false, false, false, false, true, true, true, false, false, false, false, false, false, true, false, false, false, false, false, true, true, false, false, false, false, false, false, true, true, true, false, false, false, false, false, false, true, false, true, false, false, false, false, false, false, true, true, false, false, false, false, false, false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 1
+				case 45:
+					return -1
+				case 46:
+					return 2
+				case 63:
+					return 1
+				case 91:
+					return 3
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 4
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return -1
+				case 65 <= r && r <= 90:
+					return 4
+				case 97 <= r && r <= 122:
+					return 4
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 46
+				case 45:
+					return 47
+				case 46:
+					return 47
+				case 63:
+					return 46
+				case 91:
+					return 48
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 47
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 47
+				case 65 <= r && r <= 90:
+					return 47
+				case 97 <= r && r <= 122:
+					return 47
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 37
+				case 45:
+					return -1
+				case 46:
+					return -1
+				case 63:
+					return 37
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 4
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return -1
+				case 65 <= r && r <= 90:
+					return 4
+				case 97 <= r && r <= 122:
+					return 4
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 23
+				case 42:
+					return -1
+				case 45:
+					return 23
+				case 46:
+					return 23
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 23
+				case 95:
+					return 23
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 24
+				case 65 <= r && r <= 90:
+					return 23
+				case 97 <= r && r <= 122:
+					return 23
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 5
+				case 45:
+					return 6
+				case 46:
+					return 6
+				case 63:
+					return 5
+				case 91:
+					return 7
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 6
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 6
+				case 65 <= r && r <= 90:
+					return 6
+				case 97 <= r && r <= 122:
+					return 6
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 14
+				case 45:
+					return 15
+				case 46:
+					return 15
+				case 63:
+					return 14
+				case 91:
+					return 16
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 15
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 15
+				case 65 <= r && r <= 90:
+					return 15
+				case 97 <= r && r <= 122:
+					return 15
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 5
+				case 45:
+					return 6
+				case 46:
+					return 6
+				case 63:
+					return 5
+				case 91:
+					return 7
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 6
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 6
+				case 65 <= r && r <= 90:
+					return 6
+				case 97 <= r && r <= 122:
+					return 6
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 8
+				case 42:
+					return -1
+				case 45:
+					return 8
+				case 46:
+					return 8
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 8
+				case 95:
+					return 8
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 9
+				case 65 <= r && r <= 90:
+					return 8
+				case 97 <= r && r <= 122:
+					return 8
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 10
+				case 42:
+					return -1
+				case 45:
+					return 10
+				case 46:
+					return 10
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 11
+				case 94:
+					return 10
+				case 95:
+					return 10
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 12
+				case 65 <= r && r <= 90:
+					return 10
+				case 97 <= r && r <= 122:
+					return 10
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 8
+				case 42:
+					return -1
+				case 45:
+					return 8
+				case 46:
+					return 8
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 8
+				case 95:
+					return 8
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 9
+				case 65 <= r && r <= 90:
+					return 8
+				case 97 <= r && r <= 122:
+					return 8
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 10
+				case 42:
+					return -1
+				case 45:
+					return 10
+				case 46:
+					return 10
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 11
+				case 94:
+					return 10
+				case 95:
+					return 10
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 12
+				case 65 <= r && r <= 90:
+					return 10
+				case 97 <= r && r <= 122:
+					return 10
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 13
+				case 45:
+					return 13
+				case 46:
+					return 13
+				case 63:
+					return 13
+				case 91:
+					return 7
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 13
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 13
+				case 65 <= r && r <= 90:
+					return 13
+				case 97 <= r && r <= 122:
+					return 13
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 10
+				case 42:
+					return -1
+				case 45:
+					return 10
+				case 46:
+					return 10
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 11
+				case 94:
+					return 10
+				case 95:
+					return 10
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 12
+				case 65 <= r && r <= 90:
+					return 10
+				case 97 <= r && r <= 122:
+					return 10
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 14
+				case 45:
+					return 15
+				case 46:
+					return 15
+				case 63:
+					return 14
+				case 91:
+					return 16
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 15
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 15
+				case 65 <= r && r <= 90:
+					return 15
+				case 97 <= r && r <= 122:
+					return 15
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 14
+				case 45:
+					return 15
+				case 46:
+					return 15
+				case 63:
+					return 14
+				case 91:
+					return 16
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 15
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 15
+				case 65 <= r && r <= 90:
+					return 15
+				case 97 <= r && r <= 122:
+					return 15
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 14
+				case 45:
+					return 15
+				case 46:
+					return 15
+				case 63:
+					return 14
+				case 91:
+					return 16
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 15
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 15
+				case 65 <= r && r <= 90:
+					return 15
+				case 97 <= r && r <= 122:
+					return 15
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 17
+				case 42:
+					return -1
+				case 45:
+					return 17
+				case 46:
+					return 17
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 17
+				case 95:
+					return 17
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 18
+				case 65 <= r && r <= 90:
+					return 17
+				case 97 <= r && r <= 122:
+					return 17
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 19
+				case 42:
+					return -1
+				case 45:
+					return 19
+				case 46:
+					return 19
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 20
+				case 94:
+					return 19
+				case 95:
+					return 19
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 21
+				case 65 <= r && r <= 90:
+					return 19
+				case 97 <= r && r <= 122:
+					return 19
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 17
+				case 42:
+					return -1
+				case 45:
+					return 17
+				case 46:
+					return 17
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 17
+				case 95:
+					return 17
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 18
+				case 65 <= r && r <= 90:
+					return 17
+				case 97 <= r && r <= 122:
+					return 17
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 19
+				case 42:
+					return -1
+				case 45:
+					return 19
+				case 46:
+					return 19
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 20
+				case 94:
+					return 19
+				case 95:
+					return 19
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 21
+				case 65 <= r && r <= 90:
+					return 19
+				case 97 <= r && r <= 122:
+					return 19
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 22
+				case 45:
+					return 22
+				case 46:
+					return 22
+				case 63:
+					return 22
+				case 91:
+					return 16
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 22
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 22
+				case 65 <= r && r <= 90:
+					return 22
+				case 97 <= r && r <= 122:
+					return 22
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 19
+				case 42:
+					return -1
+				case 45:
+					return 19
+				case 46:
+					return 19
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 20
+				case 94:
+					return 19
+				case 95:
+					return 19
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 21
+				case 65 <= r && r <= 90:
+					return 19
+				case 97 <= r && r <= 122:
+					return 19
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 14
+				case 45:
+					return 15
+				case 46:
+					return 15
+				case 63:
+					return 14
+				case 91:
+					return 16
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 15
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 15
+				case 65 <= r && r <= 90:
+					return 15
+				case 97 <= r && r <= 122:
+					return 15
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 25
+				case 42:
+					return -1
+				case 45:
+					return 25
+				case 46:
+					return 25
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 26
+				case 94:
+					return 25
+				case 95:
+					return 25
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 27
+				case 65 <= r && r <= 90:
+					return 25
+				case 97 <= r && r <= 122:
+					return 25
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 23
+				case 42:
+					return -1
+				case 45:
+					return 23
+				case 46:
+					return 23
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 23
+				case 95:
+					return 23
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 24
+				case 65 <= r && r <= 90:
+					return 23
+				case 97 <= r && r <= 122:
+					return 23
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 25
+				case 42:
+					return -1
+				case 45:
+					return 25
+				case 46:
+					return 25
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 26
+				case 94:
+					return 25
+				case 95:
+					return 25
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 27
+				case 65 <= r && r <= 90:
+					return 25
+				case 97 <= r && r <= 122:
+					return 25
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 28
+				case 45:
+					return 28
+				case 46:
+					return 28
+				case 63:
+					return 28
+				case 91:
+					return 3
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 28
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 28
+				case 65 <= r && r <= 90:
+					return 28
+				case 97 <= r && r <= 122:
+					return 28
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 25
+				case 42:
+					return -1
+				case 45:
+					return 25
+				case 46:
+					return 25
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 26
+				case 94:
+					return 25
+				case 95:
+					return 25
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 27
+				case 65 <= r && r <= 90:
+					return 25
+				case 97 <= r && r <= 122:
+					return 25
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 29
+				case 45:
+					return 29
+				case 46:
+					return 29
+				case 63:
+					return 29
+				case 91:
+					return 30
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 29
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 29
+				case 65 <= r && r <= 90:
+					return 29
+				case 97 <= r && r <= 122:
+					return 29
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 29
+				case 45:
+					return 29
+				case 46:
+					return 29
+				case 63:
+					return 29
+				case 91:
+					return 30
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 29
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 29
+				case 65 <= r && r <= 90:
+					return 29
+				case 97 <= r && r <= 122:
+					return 29
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 31
+				case 42:
+					return -1
+				case 45:
+					return 31
+				case 46:
+					return 31
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 31
+				case 95:
+					return 31
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 32
+				case 65 <= r && r <= 90:
+					return 31
+				case 97 <= r && r <= 122:
+					return 31
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 33
+				case 42:
+					return -1
+				case 45:
+					return 33
+				case 46:
+					return 33
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 34
+				case 94:
+					return 33
+				case 95:
+					return 33
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 35
+				case 65 <= r && r <= 90:
+					return 33
+				case 97 <= r && r <= 122:
+					return 33
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 31
+				case 42:
+					return -1
+				case 45:
+					return 31
+				case 46:
+					return 31
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 31
+				case 95:
+					return 31
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 32
+				case 65 <= r && r <= 90:
+					return 31
+				case 97 <= r && r <= 122:
+					return 31
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 33
+				case 42:
+					return -1
+				case 45:
+					return 33
+				case 46:
+					return 33
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 34
+				case 94:
+					return 33
+				case 95:
+					return 33
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 35
+				case 65 <= r && r <= 90:
+					return 33
+				case 97 <= r && r <= 122:
+					return 33
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 36
+				case 45:
+					return 36
+				case 46:
+					return 36
+				case 63:
+					return 36
+				case 91:
+					return 30
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 36
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 36
+				case 65 <= r && r <= 90:
+					return 36
+				case 97 <= r && r <= 122:
+					return 36
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 33
+				case 42:
+					return -1
+				case 45:
+					return 33
+				case 46:
+					return 33
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 34
+				case 94:
+					return 33
+				case 95:
+					return 33
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 35
+				case 65 <= r && r <= 90:
+					return 33
+				case 97 <= r && r <= 122:
+					return 33
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 29
+				case 45:
+					return 29
+				case 46:
+					return 29
+				case 63:
+					return 29
+				case 91:
+					return 30
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 29
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 29
+				case 65 <= r && r <= 90:
+					return 29
+				case 97 <= r && r <= 122:
+					return 29
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 38
+				case 45:
+					return 38
+				case 46:
+					return 38
+				case 63:
+					return 38
+				case 91:
+					return 39
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 38
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 38
+				case 65 <= r && r <= 90:
+					return 38
+				case 97 <= r && r <= 122:
+					return 38
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 38
+				case 45:
+					return 38
+				case 46:
+					return 38
+				case 63:
+					return 38
+				case 91:
+					return 39
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 38
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 38
+				case 65 <= r && r <= 90:
+					return 38
+				case 97 <= r && r <= 122:
+					return 38
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 40
+				case 42:
+					return -1
+				case 45:
+					return 40
+				case 46:
+					return 40
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 40
+				case 95:
+					return 40
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 41
+				case 65 <= r && r <= 90:
+					return 40
+				case 97 <= r && r <= 122:
+					return 40
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 42
+				case 42:
+					return -1
+				case 45:
+					return 42
+				case 46:
+					return 42
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 43
+				case 94:
+					return 42
+				case 95:
+					return 42
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 44
+				case 65 <= r && r <= 90:
+					return 42
+				case 97 <= r && r <= 122:
+					return 42
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 40
+				case 42:
+					return -1
+				case 45:
+					return 40
+				case 46:
+					return 40
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 40
+				case 95:
+					return 40
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 41
+				case 65 <= r && r <= 90:
+					return 40
+				case 97 <= r && r <= 122:
+					return 40
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 42
+				case 42:
+					return -1
+				case 45:
+					return 42
+				case 46:
+					return 42
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 43
+				case 94:
+					return 42
+				case 95:
+					return 42
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 44
+				case 65 <= r && r <= 90:
+					return 42
+				case 97 <= r && r <= 122:
+					return 42
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 45
+				case 45:
+					return 45
+				case 46:
+					return 45
+				case 63:
+					return 45
+				case 91:
+					return 39
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 45
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 45
+				case 65 <= r && r <= 90:
+					return 45
+				case 97 <= r && r <= 122:
+					return 45
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 42
+				case 42:
+					return -1
+				case 45:
+					return 42
+				case 46:
+					return 42
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 43
+				case 94:
+					return 42
+				case 95:
+					return 42
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 44
+				case 65 <= r && r <= 90:
+					return 42
+				case 97 <= r && r <= 122:
+					return 42
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 38
+				case 45:
+					return 38
+				case 46:
+					return 38
+				case 63:
+					return 38
+				case 91:
+					return 39
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 38
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 38
+				case 65 <= r && r <= 90:
+					return 38
+				case 97 <= r && r <= 122:
+					return 38
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 46
+				case 45:
+					return 47
+				case 46:
+					return 47
+				case 63:
+					return 46
+				case 91:
+					return 48
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 47
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 47
+				case 65 <= r && r <= 90:
+					return 47
+				case 97 <= r && r <= 122:
+					return 47
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 55
+				case 45:
+					return 55
+				case 46:
+					return 55
+				case 63:
+					return 55
+				case 91:
+					return 56
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 55
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 55
+				case 65 <= r && r <= 90:
+					return 55
+				case 97 <= r && r <= 122:
+					return 55
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 49
+				case 42:
+					return -1
+				case 45:
+					return 49
+				case 46:
+					return 49
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 49
+				case 95:
+					return 49
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 50
+				case 65 <= r && r <= 90:
+					return 49
+				case 97 <= r && r <= 122:
+					return 49
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 51
+				case 42:
+					return -1
+				case 45:
+					return 51
+				case 46:
+					return 51
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 52
+				case 94:
+					return 51
+				case 95:
+					return 51
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 53
+				case 65 <= r && r <= 90:
+					return 51
+				case 97 <= r && r <= 122:
+					return 51
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 49
+				case 42:
+					return -1
+				case 45:
+					return 49
+				case 46:
+					return 49
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 49
+				case 95:
+					return 49
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 50
+				case 65 <= r && r <= 90:
+					return 49
+				case 97 <= r && r <= 122:
+					return 49
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 51
+				case 42:
+					return -1
+				case 45:
+					return 51
+				case 46:
+					return 51
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 52
+				case 94:
+					return 51
+				case 95:
+					return 51
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 53
+				case 65 <= r && r <= 90:
+					return 51
+				case 97 <= r && r <= 122:
+					return 51
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 54
+				case 45:
+					return 54
+				case 46:
+					return 54
+				case 63:
+					return 54
+				case 91:
+					return 48
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 54
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 54
+				case 65 <= r && r <= 90:
+					return 54
+				case 97 <= r && r <= 122:
+					return 54
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 51
+				case 42:
+					return -1
+				case 45:
+					return 51
+				case 46:
+					return 51
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 52
+				case 94:
+					return 51
+				case 95:
+					return 51
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 53
+				case 65 <= r && r <= 90:
+					return 51
+				case 97 <= r && r <= 122:
+					return 51
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 55
+				case 45:
+					return 55
+				case 46:
+					return 55
+				case 63:
+					return 55
+				case 91:
+					return 56
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 55
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 55
+				case 65 <= r && r <= 90:
+					return 55
+				case 97 <= r && r <= 122:
+					return 55
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 55
+				case 45:
+					return 55
+				case 46:
+					return 55
+				case 63:
+					return 55
+				case 91:
+					return 56
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 55
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 55
+				case 65 <= r && r <= 90:
+					return 55
+				case 97 <= r && r <= 122:
+					return 55
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 57
+				case 42:
+					return -1
+				case 45:
+					return 57
+				case 46:
+					return 57
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 57
+				case 95:
+					return 57
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 58
+				case 65 <= r && r <= 90:
+					return 57
+				case 97 <= r && r <= 122:
+					return 57
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 58:
-					return 2
+				case 33:
+					return 59
+				case 42:
+					return -1
+				case 45:
+					return 59
+				case 46:
+					return 59
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 60
+				case 94:
+					return 59
+				case 95:
+					return 59
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 61
+				case 65 <= r && r <= 90:
+					return 59
+				case 97 <= r && r <= 122:
+					return 59
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 58:
+				case 33:
+					return 57
+				case 42:
 					return -1
+				case 45:
+					return 57
+				case 46:
+					return 57
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return -1
+				case 94:
+					return 57
+				case 95:
+					return 57
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 58
+				case 65 <= r && r <= 90:
+					return 57
+				case 97 <= r && r <= 122:
+					return 57
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 59
+				case 42:
+					return -1
+				case 45:
+					return 59
+				case 46:
+					return 59
+				case 63:
+					return -1
+				case 91:
+					return -1
+				case 93:
+					return 60
+				case 94:
+					return 59
+				case 95:
+					return 59
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 61
+				case 65 <= r && r <= 90:
+					return 59
+				case 97 <= r && r <= 122:
+					return 59
 				}
 				return -1
 			},
-		}, []int{ /* Start-of-input transitions */ -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1}, nil},
-
-		// [a-zA-Z_][a-zA-Z0-9_.-]*
-		{[]bool{false, true, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 62
 				case 45:
+					return 62
+				case 46:
+					return 62
+				case 63:
+					return 62
+				case 91:
+					return 56
+				case 93:
+					return -1
+				case 94:
+					return -1
+				case 95:
+					return 62
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 62
+				case 65 <= r && r <= 90:
+					return 62
+				case 97 <= r && r <= 122:
+					return 62
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return 59
+				case 42:
 					return -1
+				case 45:
+					return 59
 				case 46:
+					return 59
+				case 63:
+					return -1
+				case 91:
 					return -1
+				case 93:
+					return 60
+				case 94:
+					return 59
 				case 95:
-					return 1
+					return 59
 				}
 				switch {
 				case 48 <= r && r <= 57:
+					return 61
+				case 65 <= r && r <= 90:
+					return 59
+				case 97 <= r && r <= 122:
+					return 59
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 33:
+					return -1
+				case 42:
+					return 55
+				case 45:
+					return 55
+				case 46:
+					return 55
+				case 63:
+					return 55
+				case 91:
+					return 56
+				case 93:
+					return -1
+				case 94:
 					return -1
+				case 95:
+					return 55
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 55
 				case 65 <= r && r <= 90:
-					return 1
+					return 55
 				case 97 <= r && r <= 122:
-					return 1
+					return 55
 				}
 				return -1
 			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, nil},
+
+		// \.?[a-zA-Z_][a-zA-Z0-9_.-]*
+		{[]bool{false, false, true, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
 				case 45:
-					return 2
+					return -1
 				case 46:
-					return 2
+					return 1
 				case 95:
 					return 2
 				}
 				switch {
 				case 48 <= r && r <= 57:
-					return 2
+					return -1
 				case 65 <= r && r <= 90:
 					return 2
 				case 97 <= r && r <= 122:
@@ -1132,15 +5289,15 @@ func NewLexerWithInit(in io.Reader, initFun func(*Lexer)) *Lexer {
 			func(r rune) int {
 				switch r {
 				case 45:
-					return 2
+					return -1
 				case 46:
-					return 2
+					return -1
 				case 95:
 					return 2
 				}
 				switch {
 				case 48 <= r && r <= 57:
-					return 2
+					return -1
 				case 65 <= r && r <= 90:
 					return 2
 				case 97 <= r && r <= 122:
@@ -1148,158 +5305,268 @@ func NewLexerWithInit(in io.Reader, initFun func(*Lexer)) *Lexer {
 				}
 				return -1
 			},
-		}, []int{ /* Start-of-input transitions */ -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1}, nil},
+			func(r rune) int {
+				switch r {
+				case 45:
+					return 3
+				case 46:
+					return 3
+				case 95:
+					return 3
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 3
+				case 65 <= r && r <= 90:
+					return 3
+				case 97 <= r && r <= 122:
+					return 3
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 45:
+					return 3
+				case 46:
+					return 3
+				case 95:
+					return 3
+				}
+				switch {
+				case 48 <= r && r <= 57:
+					return 3
+				case 65 <= r && r <= 90:
+					return 3
+				case 97 <= r && r <= 122:
+					return 3
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1}, nil},
+
+		// [ \t\r\n]+
+		{[]bool{false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 9:
+					return 1
+				case 10:
+					return 1
+				case 13:
+					return 1
+				case 32:
+					return 1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 9:
+					return 1
+				case 10:
+					return 1
+				case 13:
+					return 1
+				case 32:
+					return 1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1}, []int{ /* End-of-input transitions */ -1, -1}, nil},
+
+		// \$
+		{[]bool{false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 36:
+					return 1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 36:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1}, []int{ /* End-of-input transitions */ -1, -1}, nil},
+
+		// #
+		{[]bool{false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 35:
+					return 1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 35:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1}, []int{ /* End-of-input transitions */ -1, -1}, nil},
+
+		// \(
+		{[]bool{false, true}, []func(rune) int{ // Transitions
+			func(r rune) int {
+				switch r {
+				case 40:
+					return 1
+				}
+				return -1
+			},
+			func(r rune) int {
+				switch r {
+				case 40:
+					return -1
+				}
+				return -1
+			},
+		}, []int{ /* Start-of-input transitions */ -1, -1}, []int{ /* End-of-input transitions */ -1, -1}, nil},
 
-		// [ \t\r\n]+
+		// \)
 		{[]bool{false, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
-				case 9:
-					return 1
-				case 10:
-					return 1
-				case 13:
-					return 1
-				case 32:
+				case 41:
 					return 1
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 9:
-					return 1
-				case 10:
-					return 1
-				case 13:
-					return 1
-				case 32:
-					return 1
+				case 41:
+					return -1
 				}
 				return -1
 			},
 		}, []int{ /* Start-of-input transitions */ -1, -1}, []int{ /* End-of-input transitions */ -1, -1}, nil},
 
-		// \$
+		// \[
 		{[]bool{false, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
-				case 36:
+				case 91:
 					return 1
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 36:
+				case 91:
 					return -1
 				}
 				return -1
 			},
 		}, []int{ /* Start-of-input transitions */ -1, -1}, []int{ /* End-of-input transitions */ -1, -1}, nil},
 
-		// #
+		// \]
 		{[]bool{false, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
-				case 35:
+				case 93:
 					return 1
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 35:
+				case 93:
 					return -1
 				}
 				return -1
 			},
 		}, []int{ /* Start-of-input transitions */ -1, -1}, []int{ /* End-of-input transitions */ -1, -1}, nil},
 
-		// \(
+		// ,
 		{[]bool{false, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
-				case 40:
+				case 44:
 					return 1
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 40:
+				case 44:
 					return -1
 				}
 				return -1
 			},
 		}, []int{ /* Start-of-input transitions */ -1, -1}, []int{ /* End-of-input transitions */ -1, -1}, nil},
 
-		// \)
+		// ;
 		{[]bool{false, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
-				case 41:
+				case 59:
 					return 1
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 41:
+				case 59:
 					return -1
 				}
 				return -1
 			},
 		}, []int{ /* Start-of-input transitions */ -1, -1}, []int{ /* End-of-input transitions */ -1, -1}, nil},
 
-		// \[
+		// \{
 		{[]bool{false, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
-				case 91:
+				case 123:
 					return 1
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 91:
+				case 123:
 					return -1
 				}
 				return -1
 			},
 		}, []int{ /* Start-of-input transitions */ -1, -1}, []int{ /* End-of-input transitions */ -1, -1}, nil},
 
-		// \]
+		// \}
 		{[]bool{false, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
-				case 93:
+				case 125:
 					return 1
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 93:
+				case 125:
 					return -1
 				}
 				return -1
 			},
 		}, []int{ /* Start-of-input transitions */ -1, -1}, []int{ /* End-of-input transitions */ -1, -1}, nil},
 
-		// ,
+		// \|
 		{[]bool{false, true}, []func(rune) int{ // Transitions
 			func(r rune) int {
 				switch r {
-				case 44:
+				case 124:
 					return 1
 				}
 				return -1
 			},
 			func(r rune) int {
 				switch r {
-				case 44:
+				case 124:
 					return -1
 				}
 				return -1
@@ -1669,149 +5936,267 @@ OUTER0:
 			}
 		case 4:
 			{
-				return OR
+				lval.str = yylex.Text()
+				return SIZE_LITERAL
 			}
 		case 5:
 			{
-				return AND
+				lval.str = yylex.Text()
+				return OR
 			}
 		case 6:
 			{
-				return INTEGER_DIVIDE
+				lval.str = yylex.Text()
+				return AND
 			}
 		case 7:
 			{
-				return DIVIDE
+				lval.str = yylex.Text()
+				return INTEGER_DIVIDE
 			}
 		case 8:
 			{
-				return MODULUS
+				lval.str = yylex.Text()
+				return DIVIDE
 			}
 		case 9:
 			{
-				return VEQ
+				lval.str = yylex.Text()
+				return MODULUS
 			}
 		case 10:
 			{
-				return VNE
+				lval.str = yylex.Text()
+				return VEQ
 			}
 		case 11:
 			{
-				return VLT
+				lval.str = yylex.Text()
+				return VNE
 			}
 		case 12:
 			{
-				return VLE
+				lval.str = yylex.Text()
+				return VLT
 			}
 		case 13:
 			{
-				return VGT
+				lval.str = yylex.Text()
+				return VLE
 			}
 		case 14:
 			{
-				return VGE
+				lval.str = yylex.Text()
+				return VGT
 			}
 		case 15:
 			{
-				return FILE
+				lval.str = yylex.Text()
+				return VGE
 			}
 		case 16:
 			{
-				return DIR
+				lval.str = yylex.Text()
+				return FILE
 			}
 		case 17:
 			{
-				return TO
+				lval.str = yylex.Text()
+				return DIR
 			}
 		case 18:
 			{
-				return AXIS
+				lval.str = yylex.Text()
+				return SYMLINK
 			}
 		case 19:
 			{
 				lval.str = yylex.Text()
-				return QNAME
+				return TO
 			}
 		case 20:
-			{ /* skip WS */
+			{
+				lval.str = yylex.Text()
+				return LET
 			}
 		case 21:
 			{
-				return DOLLAR
+				lval.str = yylex.Text()
+				return FOR
 			}
 		case 22:
 			{
-				return POUND
+				lval.str = yylex.Text()
+				return IN
 			}
 		case 23:
 			{
-				return LPAREN
+				lval.str = yylex.Text()
+				return IF
 			}
 		case 24:
 			{
-				return RPAREN
+				lval.str = yylex.Text()
+				return THEN
 			}
 		case 25:
 			{
-				return LBRACKET
+				lval.str = yylex.Text()
+				return ELSE
 			}
 		case 26:
 			{
-				return RBRACKET
+				lval.str = yylex.Text()
+				return SOME
 			}
 		case 27:
 			{
-				return COMMA
+				lval.str = yylex.Text()
+				return EVERY
 			}
 		case 28:
 			{
-				return PLUS
+				lval.str = yylex.Text()
+				return SATISFIES
 			}
 		case 29:
 			{
-				return MINUS
+				lval.str = yylex.Text()
+				return UNION
 			}
 		case 30:
 			{
-				return MULTIPLY
+				lval.str = yylex.Text()
+				return INTERSECT
 			}
 		case 31:
 			{
-				return SLASH
+				lval.str = yylex.Text()
+				return EXCEPT
 			}
 		case 32:
 			{
-				return GEQ
+				lval.str = yylex.Text()
+				return RETURN
 			}
 		case 33:
 			{
-				return GNE
+				return DECLARE_FUNCTION
 			}
 		case 34:
 			{
-				return GLT
+				return AXIS
 			}
 		case 35:
 			{
-				return GLE
+				return ASSIGN
 			}
 		case 36:
 			{
-				return GGT
+				lval.str = yylex.Text()
+				return GLOB
 			}
 		case 37:
 			{
-				return GGE
+				lval.str = yylex.Text()
+				return QNAME
 			}
 		case 38:
+			{ /* skip WS */
+			}
+		case 39:
+			{
+				return DOLLAR
+			}
+		case 40:
+			{
+				return POUND
+			}
+		case 41:
+			{
+				return LPAREN
+			}
+		case 42:
+			{
+				return RPAREN
+			}
+		case 43:
+			{
+				return LBRACKET
+			}
+		case 44:
+			{
+				return RBRACKET
+			}
+		case 45:
+			{
+				return COMMA
+			}
+		case 46:
+			{
+				return SEMICOLON
+			}
+		case 47:
+			{
+				return LBRACE
+			}
+		case 48:
+			{
+				return RBRACE
+			}
+		case 49:
+			{
+				return UNION
+			}
+		case 50:
+			{
+				return PLUS
+			}
+		case 51:
+			{
+				return MINUS
+			}
+		case 52:
+			{
+				return MULTIPLY
+			}
+		case 53:
+			{
+				return SLASH
+			}
+		case 54:
+			{
+				return GEQ
+			}
+		case 55:
+			{
+				return GNE
+			}
+		case 56:
+			{
+				return GLT
+			}
+		case 57:
+			{
+				return GLE
+			}
+		case 58:
+			{
+				return GGT
+			}
+		case 59:
+			{
+				return GGE
+			}
+		case 60:
 			{
 				return ATTR
 			}
-		case 39:
+		case 61:
 			{
 				return DOTDOT
 			}
-		case 40:
+		case 62:
 			{
 				return DOT
 			}
@@ -1825,23 +6210,272 @@ OUTER0:
 	return 0
 }
 
-var parserResult ParseTree
-
 func Parse(input io.Reader) (t ParseTree, e error) {
+	var lexer *globLexer
 	defer func() {
 		if v := recover(); v != nil {
 			t = nil
 			e = errors.New("Parse error.")
+			if lexer != nil && lexer.err != nil {
+				e = lexer.err
+			}
 		}
 	}()
-	lexer := NewLexer(input)
+	text, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	stripped, err := stripComments(string(text))
+	if err != nil {
+		return nil, err
+	}
+	// The lexer drops whatever is left over when a longer token could have
+	// matched right up to the end of input (e.g. "[a" when lexing "x[a"), so
+	// we end the input with whitespace, which never belongs to a token.
+	lexer = newGlobLexer(NewLexer(strings.NewReader(stripped + "\n")))
 	if yyParse(lexer) != 0 {
 		return nil, errors.New("Parse error.")
 	}
-	return parserResult, nil
+	return lexer.result, nil
 }
 
 func ParseString(input string) (ParseTree, error) {
 	reader := strings.NewReader(input)
 	return Parse(reader)
 }
+
+/*
+Replace each comment in a query with a space, like whitespace. Like XPath,
+comments may be nested, as in "(: outer (: inner :) :)", which a regular
+expression can't count, so they're removed before the query is lexed. Text in
+string literals is left alone.
+*/
+func stripComments(input string) (string, error) {
+	var b strings.Builder
+	depth := 0
+	var quote byte
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case depth == 0 && quote != 0:
+			// Doubled quotes in a literal close it and open it again.
+			if c == quote {
+				quote = 0
+			}
+		case depth == 0 && (c == '"' || c == '\''):
+			quote = c
+		case strings.HasPrefix(input[i:], "(:"):
+			depth++
+			i++
+			continue
+		case depth > 0 && strings.HasPrefix(input[i:], ":)"):
+			depth--
+			i++
+			if depth == 0 {
+				b.WriteByte(' ')
+			}
+			continue
+		}
+		if depth == 0 {
+			b.WriteByte(c)
+		}
+	}
+	if depth > 0 {
+		return "", errors.New("Unterminated comment.")
+	}
+	return b.String(), nil
+}
+
+/*
+A token, along with its text.
+*/
+type lexToken struct {
+	tok int
+	str string
+}
+
+/*
+globLexer wraps the Lexer to decide whether a GLOB token really is a glob. Since
+names may contain wildcards, "$x*2" and "count(*)*2" would otherwise be lexed as
+a dollar sign followed by the glob "x*2", and the glob "*2" after a parenthesis.
+Like XPath, we use the previous token to tell: a glob can't be a variable or
+attribute name, and a * right after an operand is multiplication. In those cases, the text is
+split up and lexed again.
+
+Keywords are decided the same way, so that files named "in" or "some" can still
+be selected: see keywordHere(). Names may start with a dot, like ".git", so the
+context item followed by an operator, like ".*2" or ".eq 1", is split up too:
+see dotOperator().
+
+The parser stores the tree it builds in result, so that each parse has its own,
+and any error it finds in a token which lexed correctly in err (see fail()).
+*/
+type globLexer struct {
+	lexer        *Lexer
+	pending      []lexToken
+	prev         int
+	afterOperand bool
+	result       ParseTree
+	err          error
+}
+
+func newGlobLexer(lexer *Lexer) *globLexer {
+	return &globLexer{lexer: lexer}
+}
+
+/*
+Return true if a token can be the last token of an operand.
+*/
+func endsOperand(tok int) bool {
+	switch tok {
+	case QNAME, GLOB, STRING_LITERAL, INTEGER_LITERAL, DECIMAL_LITERAL,
+		DOUBLE_LITERAL, SIZE_LITERAL, RPAREN, RBRACKET, DOT, DOTDOT:
+		return true
+	}
+	return false
+}
+
+/*
+Return true if a token can be the first token of an operand.
+*/
+func startsOperand(tok int) bool {
+	switch tok {
+	case QNAME, GLOB, STRING_LITERAL, INTEGER_LITERAL, DECIMAL_LITERAL,
+		DOUBLE_LITERAL, SIZE_LITERAL, LPAREN, DOLLAR, ATTR, DOT, DOTDOT:
+		return true
+	}
+	return false
+}
+
+/*
+Return true if a token is a keyword, like "for" or "div", rather than a name or
+a symbol.
+*/
+func isKeyword(t lexToken) bool {
+	if t.tok == QNAME || t.tok == GLOB || t.str == "" {
+		return false
+	}
+	return t.str[0] >= 'a' && t.str[0] <= 'z'
+}
+
+/*
+Lex a string into a list of tokens.
+*/
+func lexAll(str string) []lexToken {
+	var sym yySymType
+	tokens := []lexToken{}
+	lexer := NewLexer(strings.NewReader(str + "\n"))
+	for tok := lexer.Lex(&sym); tok != 0; tok = lexer.Lex(&sym) {
+		tokens = append(tokens, lexToken{tok, sym.str})
+		sym.str = ""
+	}
+	return tokens
+}
+
+/*
+Return the next token from the Lexer, without deciding what it is.
+*/
+func (l *globLexer) next() lexToken {
+	var next lexToken
+	if len(l.pending) > 0 {
+		next, l.pending = l.pending[0], l.pending[1:]
+	} else {
+		var sym yySymType
+		next = lexToken{l.lexer.Lex(&sym), sym.str}
+	}
+	return next
+}
+
+/*
+Return the kind of the token after the one being decided, without consuming it.
+*/
+func (l *globLexer) peek() int {
+	if len(l.pending) == 0 {
+		var sym yySymType
+		l.pending = append(l.pending, lexToken{l.lexer.Lex(&sym), sym.str})
+	}
+	return l.pending[0].tok
+}
+
+/*
+Return true if a keyword should be a keyword here, and not a name. Like XPath,
+operators such as "div" and "in" only come right after an operand, "for",
+"let", "some" and "every" are followed by a variable, and "if" and kind tests
+like "file" are followed by a parenthesis. After a $, anything is a name.
+*/
+func (l *globLexer) keywordHere(t lexToken) bool {
+	if l.prev == DOLLAR {
+		return false
+	}
+	switch t.tok {
+	case LET, FOR, SOME, EVERY:
+		return l.peek() == DOLLAR
+	case IF, FILE, DIR, SYMLINK:
+		return l.peek() == LPAREN
+	}
+	return l.afterOperand
+}
+
+/*
+Return true if a name or glob starting with a dot is really the context item
+followed by an operator. Right after a slash or an axis, it's always a name test,
+like "./.git" or "./.*rc". Elsewhere, ".eq" is an operator after the context
+item, and so is ".*" when a number (which can't be part of a name) or another
+operand follows it.
+*/
+func (l *globLexer) dotOperator(t lexToken) bool {
+	if len(t.str) < 2 || t.str[0] != '.' || l.prev == SLASH || l.prev == AXIS {
+		return false
+	}
+	if t.tok == QNAME {
+		rest := lexAll(t.str[1:])
+		return len(rest) == 1 && isKeyword(rest[0])
+	}
+	if t.str[1] != '*' {
+		return false
+	} else if len(t.str) > 2 {
+		return t.str[2] >= '0' && t.str[2] <= '9'
+	}
+	return startsOperand(l.peek())
+}
+
+func (l *globLexer) Lex(lval *yySymType) int {
+	next := l.next()
+	if l.dotOperator(next) {
+		l.pending = append(lexAll(next.str[1:]), l.pending...)
+		next = lexToken{DOT, ""}
+	} else if isKeyword(next) && !l.keywordHere(next) {
+		next.tok = QNAME
+	} else if next.tok == GLOB {
+		var rest string
+		end := strings.IndexAny(next.str, "*?[")
+		if (l.prev == DOLLAR || l.prev == ATTR) && end > 0 {
+			// Variables and attributes have plain names, so "$x*2" and
+			// "@size*2" are multiplications.
+			next, rest = lexToken{QNAME, next.str[:end]}, next.str[end:]
+		} else if l.afterOperand && next.str[0] == '*' {
+			next, rest = lexToken{MULTIPLY, ""}, next.str[1:]
+		}
+		if rest != "" {
+			l.pending = append(lexAll(rest), l.pending...)
+		}
+	}
+	lval.str = next.str
+	// A * is multiplication after an operand, and otherwise a wildcard.
+	l.afterOperand = endsOperand(next.tok) || next.tok == MULTIPLY && !l.afterOperand
+	l.prev = next.tok
+	return next.tok
+}
+
+func (l *globLexer) Error(e string) {
+	l.lexer.Error(e)
+}
+
+/*
+Stop parsing because of an error in a token, like a size literal which is too
+big. Parse() returns the error itself rather than a generic parse error.
+*/
+func (l *globLexer) fail(err error) {
+	l.err = err
+	l.Error(err.Error())
+}
@@ -1,5 +1,5 @@
 %{
-package main
+package dpath
 %}
 
 %union {
@@ -7,13 +7,20 @@ package main
     str string
     num int
     args []ParseTree
+    binding *VarBinding
+    bindings []*VarBinding
+    decl *FunctionDecl
+    decls []*FunctionDecl
+    names []string
 }
 
 %token  <str>           STRING_LITERAL
 %token  <str>           INTEGER_LITERAL
 %token  <str>           DECIMAL_LITERAL
 %token  <str>           DOUBLE_LITERAL
+%token  <str>           SIZE_LITERAL
 %token  <str>           QNAME
+%token  <str>           GLOB
 
 %token  <num>           OR
 %token  <num>           AND
@@ -28,8 +35,24 @@ package main
 %token  <num>           VGE
 %token  <num>           FILE
 %token  <num>           DIR
+%token  <num>           SYMLINK
 %token  <num>           TO
 %token  <num>           AXIS
+%token  <num>           LET
+%token  <num>           FOR
+%token  <num>           IN
+%token  <num>           IF
+%token  <num>           THEN
+%token  <num>           ELSE
+%token  <num>           SOME
+%token  <num>           EVERY
+%token  <num>           SATISFIES
+%token  <num>           UNION
+%token  <num>           INTERSECT
+%token  <num>           EXCEPT
+%token  <num>           RETURN
+%token  <num>           ASSIGN
+%token  <num>           DECLARE_FUNCTION
 
 %token  <num>           DOLLAR
 %token  <num>           POUND
@@ -38,6 +61,9 @@ package main
 %token  <num>           LBRACKET
 %token  <num>           RBRACKET
 %token  <num>           COMMA
+%token  <num>           SEMICOLON
+%token  <num>           LBRACE
+%token  <num>           RBRACE
 %token  <num>           PLUS
 %token  <num>           MINUS
 %token  <num>           MULTIPLY
@@ -53,8 +79,19 @@ package main
 %token  <num>           DOT
 
 %type   <tree>          XPath
+%type   <decls>         Prolog
+%type   <decl>          FunctionDecl
+%type   <names>         ParamList
 %type   <args>          Expr
 %type   <tree>          ExprSingle
+%type   <tree>          LetExpr
+%type   <bindings>      LetBindingList
+%type   <binding>       LetBinding
+%type   <tree>          ForExpr
+%type   <bindings>      ForBindingList
+%type   <binding>       ForBinding
+%type   <tree>          IfExpr
+%type   <tree>          QuantifiedExpr
 %type   <tree>          OrExpr
 %type   <tree>          AndExpr
 %type   <tree>          ComparisonExpr
@@ -63,6 +100,8 @@ package main
 %type   <tree>          RangeExpr
 %type   <tree>          AdditiveExpr
 %type   <tree>          MultiplicativeExpr
+%type   <tree>          UnionExpr
+%type   <tree>          IntersectExceptExpr
 %type   <tree>          UnaryExpr
 %type   <tree>          ValueExpr
 %type   <tree>          PathExpr
@@ -79,12 +118,28 @@ package main
 %type   <tree>          PrimaryExpr
 %type   <tree>          ParenthesizedExpr
 %type   <tree>          ContextItemExpr
+%type   <tree>          VarRef
 %type   <tree>          FunctionCall
 %type   <args>          ArgumentList
 %type   <tree>          Literal
 
 %%
-XPath:          Expr {parserResult = newSequenceTree($1)}
+XPath:          Expr {yylex.(*globLexer).result = newSequenceTree($1)}
+        |       Prolog Expr {yylex.(*globLexer).result = newModuleTree($1, newSequenceTree($2))}
+                ;
+
+Prolog:         FunctionDecl SEMICOLON {$$ = []*FunctionDecl{$1}}
+        |       Prolog FunctionDecl SEMICOLON {$$ = append($1, $2)}
+                ;
+
+FunctionDecl:   DECLARE_FUNCTION QNAME LPAREN RPAREN LBRACE Expr RBRACE
+                {$$ = newFunctionDecl($2, []string{}, newSequenceTree($6))}
+        |       DECLARE_FUNCTION QNAME LPAREN ParamList RPAREN LBRACE Expr RBRACE
+                {$$ = newFunctionDecl($2, $4, newSequenceTree($7))}
+                ;
+
+ParamList:      DOLLAR QNAME {$$ = []string{$2}}
+        |       ParamList COMMA DOLLAR QNAME {$$ = append($1, $4)}
                 ;
 
 Expr:           ExprSingle {$$ = []ParseTree{$1}}
@@ -92,6 +147,38 @@ Expr:           ExprSingle {$$ = []ParseTree{$1}}
                 ;
 
 ExprSingle:     OrExpr {$$ = $1}
+        |       LetExpr {$$ = $1}
+        |       ForExpr {$$ = $1}
+        |       IfExpr {$$ = $1}
+        |       QuantifiedExpr {$$ = $1}
+                ;
+
+LetExpr:        LET LetBindingList RETURN ExprSingle {$$ = newLetTree($2, $4)}
+                ;
+
+LetBindingList: LetBinding {$$ = []*VarBinding{$1}}
+        |       LetBindingList COMMA LetBinding {$$ = append($1, $3)}
+                ;
+
+LetBinding:     DOLLAR QNAME ASSIGN ExprSingle {$$ = newVarBinding($2, $4)}
+                ;
+
+ForExpr:        FOR ForBindingList RETURN ExprSingle {$$ = newForTree($2, $4)}
+                ;
+
+ForBindingList: ForBinding {$$ = []*VarBinding{$1}}
+        |       ForBindingList COMMA ForBinding {$$ = append($1, $3)}
+                ;
+
+ForBinding:     DOLLAR QNAME IN ExprSingle {$$ = newVarBinding($2, $4)}
+                ;
+
+IfExpr:         IF LPAREN Expr RPAREN THEN ExprSingle ELSE ExprSingle
+                {$$ = newIfTree(newSequenceTree($3), $6, $8)}
+                ;
+
+QuantifiedExpr: SOME ForBindingList SATISFIES ExprSingle {$$ = newQuantifiedTree("some", $2, $4)}
+        |       EVERY ForBindingList SATISFIES ExprSingle {$$ = newQuantifiedTree("every", $2, $4)}
                 ;
 
 OrExpr:         AndExpr {$$ = $1}
@@ -133,11 +220,21 @@ AdditiveExpr:   MultiplicativeExpr {$$ = $1}
                 ;
 
 MultiplicativeExpr:
+                UnionExpr {$$ = $1}
+        |       MultiplicativeExpr MULTIPLY UnionExpr {$$ = newBinopTree("*", $1, $3)}
+        |       MultiplicativeExpr DIVIDE UnionExpr {$$ = newBinopTree("div", $1, $3)}
+        |       MultiplicativeExpr INTEGER_DIVIDE UnionExpr {$$ = newBinopTree("idiv", $1, $3)}
+        |       MultiplicativeExpr MODULUS UnionExpr {$$ = newBinopTree("mod", $1, $3)}
+                ;
+
+UnionExpr:      IntersectExceptExpr {$$ = $1}
+        |       UnionExpr UNION IntersectExceptExpr {$$ = newBinopTree("union", $1, $3)}
+                ;
+
+IntersectExceptExpr:
                 UnaryExpr {$$ = $1}
-        |       MultiplicativeExpr MULTIPLY UnaryExpr {$$ = newBinopTree("*", $1, $3)}
-        |       MultiplicativeExpr DIVIDE UnaryExpr {$$ = newBinopTree("div", $1, $3)}
-        |       MultiplicativeExpr INTEGER_DIVIDE UnaryExpr {$$ = newBinopTree("idiv", $1, $3)}
-        |       MultiplicativeExpr MODULUS UnaryExpr {$$ = newBinopTree("mod", $1, $3)}
+        |       IntersectExceptExpr INTERSECT UnaryExpr {$$ = newBinopTree("intersect", $1, $3)}
+        |       IntersectExceptExpr EXCEPT UnaryExpr {$$ = newBinopTree("except", $1, $3)}
                 ;
 
 UnaryExpr:      ValueExpr {$$ = $1}
@@ -171,7 +268,7 @@ StepExpr:       AxisStep {$$ = $1}
                 ;
 
 AxisStep:       NodeStep {$$ = $1}
-        |       NodeStep PredicateList {$$ = newFilteredSequenceTree($1, $2)}
+        |       NodeStep PredicateList {$$ = newFilteredStepTree($1, $2)}
                 ;
 
 NodeStep:       QNAME AXIS NodeTest {$$ = newAxisTree($1, $3)}
@@ -186,11 +283,13 @@ NodeTest:       KindTest {$$ = $1}
 
 NameTest:       QNAME {$$ = newNameTree($1)}
         |       MULTIPLY {$$ = newKindTree("*")}
+        |       GLOB {$$ = newGlobTree($1)}
         |       POUND STRING_LITERAL {$$ = newNameTree(parseStringLiteral($2))}
                 ;
 
 KindTest:       FILE LPAREN RPAREN {$$ = newKindTree("file")}
         |       DIR LPAREN RPAREN {$$ = newKindTree("dir")}
+        |       SYMLINK LPAREN RPAREN {$$ = newKindTree("symlink")}
                 ;
 
 PredicateList:  Predicate {$$ = []ParseTree{$1}}
@@ -208,6 +307,7 @@ PrimaryExpr:    Literal {$$ = $1}
         |       ParenthesizedExpr {$$ = $1}
         |       ContextItemExpr {$$ = $1}
         |       FunctionCall {$$ = $1}
+        |       VarRef {$$ = $1}
                 ;
 
 ParenthesizedExpr:
@@ -218,6 +318,9 @@ ParenthesizedExpr:
 ContextItemExpr:DOT {$$ = newContextItemTree()}
                 ;
 
+VarRef:         DOLLAR QNAME {$$ = newVarRefTree($2)}
+                ;
+
 FunctionCall:   QNAME LPAREN RPAREN {$$ = newFunccallTree($1, []ParseTree{})}
         |       QNAME LPAREN ArgumentList RPAREN {$$ = newFunccallTree($1, $3)}
                 ;
@@ -230,6 +333,14 @@ Literal:        STRING_LITERAL {$$ = newStringTree($1)}
         |       INTEGER_LITERAL {$$ = newIntegerTree($1)}
         |       DECIMAL_LITERAL {$$ = newDoubleTree($1)}
         |       DOUBLE_LITERAL {$$ = newDoubleTree($1)}
+        |       SIZE_LITERAL
+                {
+                    size, err := newSizeTree($1)
+                    if err != nil {
+                        yylex.(*globLexer).fail(err)
+                    }
+                    $$ = size
+                }
                 ;
 
 %%
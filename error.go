@@ -2,7 +2,7 @@
 error.go contains the chainedError type.
 */
 
-package main
+package dpath
 
 /*
 ChainedError holds a custom message and an "original" error, so you can report
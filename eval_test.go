@@ -1,8 +1,16 @@
-package main
+package dpath
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestIntegerLiteral(t *testing.T) {
@@ -293,3 +301,933 @@ func TestLeftAssociativityLogic(t *testing.T) {
 		assert.Equal(t, results[i], getBool(item), uut)
 	}
 }
+
+func TestLetExpression(t *testing.T) {
+	cases := []string{
+		"let $x := 5 return $x",
+		"let $x := 2, $y := $x * 3 return $x + $y",
+		"let $x := 1 return let $x := $x + 1 return $x",
+		"let $x := (1, 2, 3) return count($x) + count($x)",
+		"let $file := 1 return $file",
+		"let $in := 2, $div := 3 return $in * $div",
+		"for $dir in 4 return $dir",
+	}
+	results := []int64{5, 8, 2, 6, 1, 6, 4}
+	for i, uut := range cases {
+		seq, ctx := assertEvaluates(t, uut)
+		item := assertSingleton(t, ctx, seq)
+		assert.Equal(t, results[i], getInteger(item), uut)
+		assert.Empty(t, ctx.Variables, uut)
+	}
+}
+
+func TestLetExpressionLazyScope(t *testing.T) {
+	// The predicate is only evaluated once the result sequence is iterated,
+	// after the let expression has been evaluated.
+	seq, ctx := assertEvaluates(t, "let $x := 2 return (1 to 5)[. mod $x eq 0]")
+	items, err := seqToSlice(seq, ctx)
+	assert.Nil(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, int64(2), getInteger(items[0]))
+	assert.Equal(t, int64(4), getInteger(items[1]))
+	assert.Empty(t, ctx.Variables)
+}
+
+func TestUnboundVariableFails(t *testing.T) {
+	cases := []string{
+		"$x",
+		"(let $x := 1 return $x) + $x",
+	}
+	for _, uut := range cases {
+		tree := assertParses(t, uut)
+		ctx := MockDefaultContext()
+		_, err := tree.Evaluate(ctx)
+		assert.Error(t, err, uut)
+	}
+}
+
+func TestForExpression(t *testing.T) {
+	cases := []string{
+		"for $x in (1 to 3) return $x * 2",
+		"for $x in (1, 2), $y in ($x to 2) return $x * 10 + $y",
+		"for $x in (1 to 6) return (1 to 6)[. eq $x][. mod 3 eq 0]",
+		"for $x in () return $x",
+	}
+	results := [][]int64{{2, 4, 6}, {11, 12, 22}, {3, 6}, {}}
+	for i, uut := range cases {
+		seq, ctx := assertEvaluates(t, uut)
+		items, err := seqToSlice(seq, ctx)
+		assert.Nil(t, err, uut)
+		assert.Len(t, items, len(results[i]), uut)
+		for j, item := range items {
+			assert.Equal(t, results[i][j], getInteger(item), uut)
+		}
+		assert.Empty(t, ctx.Variables, uut)
+	}
+}
+
+func TestForExpressionFails(t *testing.T) {
+	seq, ctx := assertEvaluates(t, "for $x in (1, 'a', 3) return $x + 1")
+	_, err := seqToSlice(seq, ctx)
+	assert.Error(t, err)
+}
+
+func TestIfExpression(t *testing.T) {
+	cases := []string{
+		"if (1) then 'yes' else 'no'",
+		"if (0) then 'yes' else 'no'",
+		"if (()) then 'yes' else 'no'",
+		"if ('') then 'yes' else if ('a') then 'maybe' else 'no'",
+		"for $x in (1 to 3) return if ($x mod 2 eq 0) then 'even' else 'odd'",
+	}
+	results := [][]string{{"yes"}, {"no"}, {"no"}, {"maybe"}, {"odd", "even", "odd"}}
+	for i, uut := range cases {
+		seq, ctx := assertEvaluates(t, uut)
+		items, err := seqToSlice(seq, ctx)
+		assert.Nil(t, err, uut)
+		assert.Len(t, items, len(results[i]), uut)
+		for j, item := range items {
+			assert.Equal(t, results[i][j], getString(item), uut)
+		}
+	}
+}
+
+func TestIfExpressionOnlyEvaluatesBranchTaken(t *testing.T) {
+	// The branch not taken would raise an error if it were evaluated.
+	cases := []string{
+		"if (1) then 2 else 1 + 'a'",
+		"if (0) then $unbound else 2",
+	}
+	for _, uut := range cases {
+		seq, ctx := assertEvaluates(t, uut)
+		item := assertSingleton(t, ctx, seq)
+		assert.Equal(t, int64(2), getInteger(item), uut)
+	}
+}
+
+func TestIfExpressionWithoutBoolean(t *testing.T) {
+	// The condition doesn't depend on boolean() being in the namespace.
+	tree := assertParses(t, "if ('a') then 1 else 2")
+	ctx := MockDefaultContext()
+	ctx.Namespace = map[string]Builtin{}
+	seq, err := tree.Evaluate(ctx)
+	assert.Nil(t, err)
+	item := assertSingleton(t, ctx, seq)
+	assert.Equal(t, int64(1), getInteger(item))
+}
+
+func TestIfExpressionFails(t *testing.T) {
+	tree := assertParses(t, "if ((1, 2)) then 1 else 2")
+	ctx := MockDefaultContext()
+	_, err := tree.Evaluate(ctx)
+	assert.Error(t, err)
+}
+
+func TestQuantifiedExpression(t *testing.T) {
+	cases := []string{
+		"some $x in (1 to 5) satisfies $x gt 4",
+		"some $x in (1 to 5) satisfies $x gt 5",
+		"some $x in () satisfies $x",
+		"every $x in (1 to 5) satisfies $x le 5",
+		"every $x in (1 to 5) satisfies $x lt 5",
+		"every $x in () satisfies $x",
+		"some $x in (1, 2), $y in (3, 4) satisfies $x + $y eq 6",
+		"every $x in (1, 2), $y in (3, 4) satisfies $x + $y lt 6",
+	}
+	results := []bool{true, false, false, true, false, true, true, false}
+	for i, uut := range cases {
+		seq, ctx := assertEvaluates(t, uut)
+		item := assertSingleton(t, ctx, seq)
+		assert.Equal(t, results[i], getBool(item), uut)
+		assert.Empty(t, ctx.Variables, uut)
+	}
+}
+
+func TestQuantifiedExpressionShortCircuits(t *testing.T) {
+	// The condition would raise an error for the string, if it got that far.
+	cases := []string{
+		"some $x in (1, 'a') satisfies $x eq 1",
+		"every $x in (1, 'a') satisfies $x ne 1",
+	}
+	results := []bool{true, false}
+	for i, uut := range cases {
+		seq, ctx := assertEvaluates(t, uut)
+		item := assertSingleton(t, ctx, seq)
+		assert.Equal(t, results[i], getBool(item), uut)
+	}
+}
+
+func TestQuantifiedExpressionWithoutBoolean(t *testing.T) {
+	// The condition doesn't depend on boolean() being in the namespace.
+	cases := map[string]bool{
+		"some $x in (0, 'a') satisfies $x":  true,
+		"every $x in (1, '') satisfies $x":  false,
+		"every $x in ('a', 2) satisfies $x": true,
+	}
+	for uut, expected := range cases {
+		tree := assertParses(t, uut)
+		ctx := MockDefaultContext()
+		ctx.Namespace = map[string]Builtin{}
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, uut)
+		item := assertSingleton(t, ctx, seq)
+		assert.Equal(t, expected, getBool(item), uut)
+	}
+}
+
+func mockFiles(names ...string) Sequence {
+	files := make([]Item, 0, len(names))
+	for _, name := range names {
+		files = append(files, MockFile("/MockedDir/"+name, name, false))
+	}
+	return newWrapperSequence(files)
+}
+
+func assertFileNames(t *testing.T, ctx *Context, seq Sequence, names ...string) {
+	items, err := seqToSlice(seq, ctx)
+	assert.Nil(t, err)
+	assert.Len(t, items, len(names))
+	for i, item := range items {
+		assert.Equal(t, names[i], item.ToString())
+	}
+}
+
+func TestFileSetOperators(t *testing.T) {
+	ctx := MockDefaultContext()
+
+	seq, err := FileUnion(ctx, mockFiles("a", "b", "a"), mockFiles("c", "b"))
+	assert.Nil(t, err)
+	assertFileNames(t, ctx, seq, "a", "b", "c")
+
+	// The union is in document order, whichever side the files come from.
+	seq, err = FileUnion(ctx, mockFiles("b", "d", "e"), mockFiles("a", "c", "d", "f"))
+	assert.Nil(t, err)
+	assertFileNames(t, ctx, seq, "a", "b", "c", "d", "e", "f")
+
+	seq, err = FileIntersect(ctx, mockFiles("a", "b", "c", "b"), mockFiles("c", "b"))
+	assert.Nil(t, err)
+	assertFileNames(t, ctx, seq, "b", "c")
+
+	seq, err = FileExcept(ctx, mockFiles("a", "b", "c", "a"), mockFiles("c", "b"))
+	assert.Nil(t, err)
+	assertFileNames(t, ctx, seq, "a")
+}
+
+func TestFileSetOperatorsFail(t *testing.T) {
+	cases := []string{
+		"(1, 2) | ()",
+		"() union 'a'",
+		"(1, 2) intersect ()",
+		"() except 3",
+	}
+	for _, uut := range cases {
+		tree := assertParses(t, uut)
+		ctx := MockDefaultContext()
+		seq, err := tree.Evaluate(ctx)
+		if err == nil {
+			_, err = seqToSlice(seq, ctx)
+		}
+		assert.Error(t, err, uut)
+	}
+}
+
+func TestFileSetOperatorOrder(t *testing.T) {
+	ctx := mapFSContext(t, fstest.MapFS{
+		"a":   {Data: []byte("a\n")},
+		"b/x": {Data: []byte("x\n")},
+		"c":   {Data: []byte("c\n")},
+	})
+	// Operands which aren't in document order are sorted, so the results of
+	// set operators always are, like the results of paths.
+	cases := map[string][]string{
+		"(./c, ./a) | ()":                {"/a", "/c"},
+		"() union (c, b/x, a, c)":        {"/a", "/b/x", "/c"},
+		"(c, b) union (b/x, a)":          {"/a", "/b", "/b/x", "/c"},
+		"(c, b/x, a) intersect (a, c)":   {"/a", "/c"},
+		"(c, b/x, a) except b/x":         {"/a", "/c"},
+		"let $f := (c, a) return $f | b": {"/a", "/b", "/c"},
+		"(c, a)[1] union (b, a)[1]":      {"/b", "/c"},
+	}
+	for expr, expected := range cases {
+		items, err := evaluateAll(ctx, expr)
+		assert.Nil(t, err, expr)
+		var actual []string
+		for _, item := range items {
+			actual = append(actual, item.(*FileItem).Path)
+		}
+		assert.Equal(t, expected, actual, expr)
+	}
+}
+
+func TestPositionalPredicates(t *testing.T) {
+	cases := []string{
+		"(1 to 10)[3]",
+		"(1 to 10)[last()]",
+		"(1 to 10)[position() le 3]",
+		"(1 to 10)[. mod 2 eq 0][2]",
+		"(1 to 10)[position() mod 2 eq 0][last() - 1]",
+		"(1 to 10)[2.0]",
+		"(1 to 10)[11]",
+		"(5, 6, 7)[position() eq last()]",
+		"for $x in (1 to 3) return (4 to 6)[$x]",
+	}
+	results := [][]int64{
+		{3}, {10}, {1, 2, 3}, {4}, {8}, {2}, {}, {7}, {4, 5, 6},
+	}
+	for i, uut := range cases {
+		seq, ctx := assertEvaluates(t, uut)
+		items, err := seqToSlice(seq, ctx)
+		assert.Nil(t, err, uut)
+		assert.Len(t, items, len(results[i]), uut)
+		for j, item := range items {
+			assert.Equal(t, results[i][j], getInteger(item), uut)
+		}
+	}
+}
+
+func TestPositionalPredicateStopsEarly(t *testing.T) {
+	// The source is huge, so this would take forever if we didn't stop after
+	// finding the third item.
+	seq, ctx := assertEvaluates(t, "(1 to 9223372036854775806)[3]")
+	item := assertSingleton(t, ctx, seq)
+	assert.Equal(t, int64(3), getInteger(item))
+}
+
+/*
+fixedAxis is an axis which always returns the same files, used to test how
+steps treat the order of axis results.
+*/
+type fixedAxis struct {
+	Files   []string
+	Reverse bool
+}
+
+func (a *fixedAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	return newConditionFilter(mockFiles(a.Files...), func(i Item) bool {
+		return i.(*FileItem).Info.Name() == name
+	}), nil
+}
+
+func (a *fixedAxis) Iterate(ctx *Context) (Sequence, error) {
+	return mockFiles(a.Files...), nil
+}
+
+func (a *fixedAxis) IsReverse() bool { return a.Reverse }
+
+func TestReverseAxisPredicates(t *testing.T) {
+	cases := []struct {
+		expr    string
+		reverse bool
+		names   []string
+	}{
+		{"ancestor::*[1]", true, []string{"c"}},
+		{"ancestor::*[position() le 2]", true, []string{"b", "c"}},
+		{"ancestor::*[last()]", true, []string{"a"}},
+		{"(ancestor::*)[1]", true, []string{"a"}},
+		{"ancestor::*[1]", false, []string{"a"}},
+	}
+	for _, c := range cases {
+		tree := assertParses(t, c.expr)
+		ctx := MockDefaultContext()
+		ctx.Axes["ancestor"] = &fixedAxis{Files: []string{"a", "b", "c"}, Reverse: c.reverse}
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, c.expr)
+		assertFileNames(t, ctx, seq, c.names...)
+	}
+}
+
+func TestDocumentOrder(t *testing.T) {
+	ordered := []string{"/", "/a", "/a/b", "/a/b/c", "/a/c", "/a-b", "/b"}
+	for i, left := range ordered {
+		for j, right := range ordered {
+			cmp := documentOrder(MockFile(left, "", false), MockFile(right, "", false))
+			switch {
+			case i < j:
+				assert.True(t, cmp < 0, "%s before %s", left, right)
+			case i > j:
+				assert.True(t, cmp > 0, "%s after %s", left, right)
+			default:
+				assert.Equal(t, 0, cmp, left)
+			}
+		}
+	}
+}
+
+/*
+mapAxis is an axis which maps the path of the context item to a list of paths.
+*/
+type mapAxis struct {
+	Paths   map[string][]string
+	Reverse bool
+}
+
+func (a *mapAxis) Iterate(ctx *Context) (Sequence, error) {
+	files := []Item{}
+	for _, p := range a.Paths[ctx.ContextItem.(*FileItem).Path] {
+		files = append(files, MockFile(p, path.Base(p), true))
+	}
+	return newWrapperSequence(files), nil
+}
+
+func (a *mapAxis) GetByName(ctx *Context, name string) (Sequence, error) {
+	seq, err := a.Iterate(ctx)
+	return newConditionFilter(seq, func(i Item) bool {
+		return i.(*FileItem).Info.Name() == name
+	}), err
+}
+
+func (a *mapAxis) IsReverse() bool { return a.Reverse }
+
+func assertFilePaths(t *testing.T, ctx *Context, seq Sequence, paths ...string) {
+	items, err := seqToSlice(seq, ctx)
+	assert.Nil(t, err)
+	assert.Len(t, items, len(paths))
+	for i, item := range items {
+		assert.Equal(t, paths[i], item.(*FileItem).Path)
+	}
+}
+
+func mapAxisContext() *Context {
+	ctx := MockDefaultContext()
+	ctx.ContextItem = MockFile("/r", "r", true)
+	ctx.Axes["child"] = &mapAxis{Paths: map[string][]string{
+		"/r":   {"/r/a/x", "/r/a/y", "/r/b/z"},
+		"/r/a": {"/r/a/b"},
+		"/r/c": {"/r/c/e"},
+	}}
+	ctx.Axes["parent"] = &mapAxis{Reverse: true, Paths: map[string][]string{
+		"/r/a/x": {"/r/a"},
+		"/r/a/y": {"/r/a"},
+		"/r/b/z": {"/r/b"},
+	}}
+	ctx.Axes["descendant"] = &mapAxis{Paths: map[string][]string{
+		"/r":     {"/r/a", "/r/a/b", "/r/c"},
+		"/r/a":   {"/r/a/b", "/r/a/b/d"},
+		"/r/a/b": {"/r/a/b/d"},
+		"/r/c":   {"/r/c/e"},
+	}}
+	ctx.CurrentAxis = ctx.Axes["child"]
+	return ctx
+}
+
+func TestPathDuplicateElimination(t *testing.T) {
+	cases := []struct {
+		expr  string
+		paths []string
+	}{
+		{"*/..", []string{"/r/a", "/r/b"}},
+		{"*/parent::*", []string{"/r/a", "/r/b"}},
+		{"descendant::*/descendant::*", []string{"/r/a/b", "/r/a/b/d", "/r/c/e"}},
+		{"$x/*", []string{"/r/a/b", "/r/c/e"}},
+		{"($x, $x)/*", []string{"/r/a/b", "/r/c/e"}},
+	}
+	for _, c := range cases {
+		tree := assertParses(t, c.expr)
+		ctx := mapAxisContext()
+		ctx.Variables["x"] = []Item{MockFile("/r/c", "c", true), MockFile("/r/a", "a", true)}
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, c.expr)
+		assertFilePaths(t, ctx, seq, c.paths...)
+	}
+}
+
+/*
+errorSequence returns an error instead of advancing.
+*/
+type errorSequence struct{}
+
+func (s *errorSequence) Next(ctx *Context) (bool, error) { return false, errors.New("no more") }
+func (s *errorSequence) Value() Item                     { return nil }
+
+func TestPathSequenceStreams(t *testing.T) {
+	ctx := mapAxisContext()
+	input := newConcatenateSequence(
+		newWrapperSequence([]Item{MockFile("/r/a", "a", true), MockFile("/r/c", "c", true)}),
+		&errorSequence{},
+	)
+	seq := newPathSequence(input, newKindTree("*"), true)
+
+	// The first file is released as soon as the path moves past /r/a, before
+	// the input is exhausted.
+	hasNext, err := seq.Next(ctx)
+	assert.True(t, hasNext)
+	assert.Nil(t, err)
+	assert.Equal(t, "/r/a/b", seq.Value().(*FileItem).Path)
+
+	_, err = seq.Next(ctx)
+	assert.Error(t, err)
+}
+
+func TestAttributeAxis(t *testing.T) {
+	ctx := MockDefaultContext()
+	ctx.Axes["attribute"] = &AttributeAxis{}
+	ctx.ContextItem = MockFile("/MockedDir/a", "a", false)
+
+	// Mocked files have no Stat_t, so only the portable attributes exist.
+	seq, err := ctx.Axes["attribute"].Iterate(ctx)
+	assert.Nil(t, err)
+	items, err := seqToSlice(seq, ctx)
+	assert.Nil(t, err)
+	names := []string{}
+	for _, item := range items {
+		assert.Equal(t, TYPE_ATTR, item.TypeName())
+		names = append(names, item.(*AttributeItem).Name)
+	}
+	assert.Equal(t, []string{"size", "mtime", "perm"}, names)
+
+	cases := map[string]Item{
+		"@size":                        newIntegerItem(1024),
+		"@perm":                        newStringItem("0000"),
+		"@size + 1":                    newIntegerItem(1025),
+		"1024 = @*":                    newBooleanItem(true),
+		"@*[1] eq 1024":                newBooleanItem(true),
+		"-@*[1]":                       newIntegerItem(-1024),
+		"name(@*[3])":                  newStringItem("perm"),
+		"string(@*[name() = 'size'])":  newStringItem("1024"),
+		"count(@*[name() = 'inode'])":  newIntegerItem(0),
+		"empty(attribute::#\"nlink\")": newBooleanItem(true),
+	}
+	for expr, expected := range cases {
+		tree := assertParses(t, expr)
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, expr)
+		item := assertSingleton(t, ctx, seq)
+		assert.Equal(t, expected, item, expr)
+	}
+}
+
+func TestGlobNameTest(t *testing.T) {
+	cases := []struct {
+		expr  string
+		paths []string
+	}{
+		{"*.txt", []string{"/r/a.txt", "/r/c.txt"}},
+		{"[ab]*", []string{"/r/a.txt", "/r/b.go"}},
+		{"[!ab]*", []string{"/r/c.txt", "/r/d"}},
+		{"?.*", []string{"/r/a.txt", "/r/b.go", "/r/c.txt"}},
+		{"*.md", []string{}},
+	}
+	for _, c := range cases {
+		tree := assertParses(t, c.expr)
+		ctx := MockDefaultContext()
+		ctx.ContextItem = MockFile("/r", "r", true)
+		ctx.CurrentAxis = &mapAxis{Paths: map[string][]string{
+			"/r": {"/r/a.txt", "/r/b.go", "/r/c.txt", "/r/d"},
+		}}
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, c.expr)
+		assertFilePaths(t, ctx, seq, c.paths...)
+	}
+
+	tree := assertParses(t, "[a-]*")
+	_, err := tree.Evaluate(MockDefaultContext())
+	assert.Error(t, err)
+}
+
+func TestGlobChildAxis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dpath")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"b.go", "a.go", "a_test.go", "c.txt"} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	ctx := MockDefaultContext()
+	ctx.ContextItem, err = newFileItem(ctx, dir)
+	assert.Nil(t, err)
+
+	seq, err := getByPattern(ctx, &ChildAxis{}, "*.go")
+	assert.Nil(t, err)
+	assertFileNames(t, ctx, seq, "a.go", "a_test.go", "b.go")
+
+	ctx.ContextItem = MockFile("/MockedDir/a", "a", false)
+	ctx.Axes["attribute"] = &AttributeAxis{}
+	seq, err = getByPattern(ctx, ctx.Axes["attribute"], "*e")
+	assert.Nil(t, err)
+	assertFileNames(t, ctx, seq, "1024", "0001-01-01T00:00:00Z")
+}
+
+func TestSiblingAxes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dpath")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a", "b"} {
+		assert.Nil(t, os.Mkdir(filepath.Join(dir, name), 0755))
+	}
+	for _, name := range []string{"LICENSE", "go.mod", "z", "b/go.mod"} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	cases := []struct {
+		expr  string
+		names []string
+	}{
+		{"a/following-sibling::*", []string{"b", "go.mod", "z"}},
+		{"z/preceding-sibling::*", []string{"LICENSE", "a", "b", "go.mod"}},
+		{"z/preceding-sibling::*[1]", []string{"go.mod"}},
+		{"z/preceding-sibling::a", []string{"a"}},
+		{"a/following-sibling::LICENSE", []string{}},
+		{"go.mod/sibling::LICENSE", []string{"LICENSE"}},
+		{"go.mod/sibling::go.mod", []string{}},
+		{"*/sibling::*", []string{"LICENSE", "a", "b", "go.mod", "z"}},
+		{"*/go.mod[empty(sibling::LICENSE)]", []string{"b/go.mod"}},
+	}
+	for _, c := range cases {
+		tree := assertParses(t, c.expr)
+		ctx := MockDefaultContext()
+		ctx.Axes["child"] = &ChildAxis{}
+		ctx.Axes["parent"] = &ParentAxis{}
+		ctx.Axes["following-sibling"] = &FollowingSiblingAxis{}
+		ctx.Axes["preceding-sibling"] = &PrecedingSiblingAxis{}
+		ctx.Axes["sibling"] = &SiblingAxis{}
+		ctx.CurrentAxis = ctx.Axes["child"]
+		ctx.ContextItem, err = newFileItem(ctx, dir)
+		assert.Nil(t, err)
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, c.expr)
+		paths := []string{}
+		for _, name := range c.names {
+			paths = append(paths, path.Join(dir, name))
+		}
+		assertFilePaths(t, ctx, seq, paths...)
+	}
+
+	// The root directory has no siblings.
+	ctx := MockDefaultContext()
+	ctx.Axes["parent"] = &ParentAxis{}
+	ctx.ContextItem = MockFile("/", "/", true)
+	seq, err := (&SiblingAxis{}).Iterate(ctx)
+	assert.Nil(t, err)
+	assertFilePaths(t, ctx, seq)
+}
+
+func TestFollowingPrecedingSelfAxes(t *testing.T) {
+	cases := []struct {
+		expr  string
+		paths []string
+	}{
+		{"a/x/following::*", []string{"/a/y", "/a/y/q", "/b", "/c", "/c/d"}},
+		{"a/x/following::q", []string{"/a/y/q"}},
+		{"a/x/following::*[1]", []string{"/a/y"}},
+		{"c/d/preceding::*", []string{"/a", "/a/x", "/a/y", "/a/y/q", "/b"}},
+		{"c/d/preceding::*[1]", []string{"/b"}},
+		{"c/d/preceding::*[2]", []string{"/a/y/q"}},
+		{"*/following::*", []string{"/b", "/c", "/c/d"}},
+		{"*/self::b", []string{"/b"}},
+		{"*/self::dir()", []string{"/a", "/b", "/c"}},
+		{"*/self::file()", []string{}},
+		{"a/x/following-sibling::*", []string{"/a/y"}},
+	}
+	for _, c := range cases {
+		tree := assertParses(t, c.expr)
+		ctx := MockDefaultContext()
+		ctx.ContextItem = MockFile("/", "", true)
+		ctx.Axes["child"] = &mapAxis{Paths: map[string][]string{
+			"/":    {"/a", "/b", "/c"},
+			"/a":   {"/a/x", "/a/y"},
+			"/a/y": {"/a/y/q"},
+			"/c":   {"/c/d"},
+		}}
+		ctx.Axes["parent"] = &mapAxis{Reverse: true, Paths: map[string][]string{
+			"/a": {"/"}, "/b": {"/"}, "/c": {"/"},
+			"/a/x": {"/a"}, "/a/y": {"/a"}, "/a/y/q": {"/a/y"}, "/c/d": {"/c"},
+		}}
+		ctx.Axes["following"] = &FollowingAxis{}
+		ctx.Axes["preceding"] = &PrecedingAxis{}
+		ctx.Axes["following-sibling"] = &FollowingSiblingAxis{}
+		ctx.Axes["self"] = &SelfAxis{}
+		ctx.CurrentAxis = ctx.Axes["child"]
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, c.expr)
+		assertFilePaths(t, ctx, seq, c.paths...)
+	}
+}
+
+func TestSymbolicLinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dpath")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "repo", "a"), 0755))
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "shared", "lib"), 0755))
+	for link, target := range map[string]string{
+		"repo/s":      "../shared",
+		"repo/a/up":   "..",
+		"repo/broken": "nowhere",
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Skip("symbolic links are not supported:", err)
+		}
+	}
+	real, err := filepath.EvalSymlinks(dir)
+	assert.Nil(t, err)
+
+	cases := []struct {
+		expr   string
+		follow bool
+		paths  []string
+	}{
+		{".//*", false, []string{"a", "a/up", "broken", "s"}},
+		// The link back up to the start is a cycle, so only its children are
+		// listed, and not its descendants.
+		{".//*", true, []string{
+			"a", "a/up", "a/up/a", "a/up/broken", "a/up/s", "broken", "s", "s/lib",
+		}},
+		{".//symlink()", false, []string{"a/up", "broken", "s"}},
+		{"*/self::dir()", false, []string{"a"}},
+		{"*/self::dir()", true, []string{"a", "s"}},
+		{"s/*", false, []string{}},
+		{"s/*", true, []string{"s/lib"}},
+		{"s/link-target::*", false, []string{real + "/shared"}},
+		{"(a, broken)/link-target::*", false, []string{}},
+	}
+	for _, c := range cases {
+		tree := assertParses(t, c.expr)
+		ctx := MockDefaultContext()
+		ctx.Axes["child"] = &ChildAxis{}
+		ctx.Axes["descendant-or-self"] = &DescendantOrSelfAxis{}
+		ctx.Axes["self"] = &SelfAxis{}
+		ctx.Axes["link-target"] = &LinkTargetAxis{}
+		ctx.CurrentAxis = ctx.Axes["child"]
+		ctx.FollowLinks = c.follow
+		ctx.ContextItem, err = newFileItem(ctx, filepath.Join(dir, "repo"))
+		assert.Nil(t, err)
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, c.expr)
+		paths := []string{}
+		for _, p := range c.paths {
+			if !path.IsAbs(p) {
+				p = path.Join(dir, "repo", p)
+			}
+			paths = append(paths, p)
+		}
+		assertFilePaths(t, ctx, seq, paths...)
+	}
+}
+
+func TestLineAxis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dpath")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	// The long line crosses the boundary between the first two blocks.
+	long := strings.Repeat("x", lineBlockSize)
+	contents := "package main\r\n// TODO: one\n" + long + "\n\n// TODO: two"
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(contents), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "b.go"), []byte("package b\n"), 0644))
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "c"), 0755))
+
+	ctx := MockDefaultContext()
+	ctx.Axes["line"] = &LineAxis{}
+	ctx.ContextItem, err = newFileItem(ctx, filepath.Join(dir, "a.go"))
+	assert.Nil(t, err)
+	seq, err := ctx.Axes["line"].Iterate(ctx)
+	assert.Nil(t, err)
+	items, err := seqToSlice(seq, ctx)
+	assert.Nil(t, err)
+	expected := []string{"package main", "// TODO: one", long, "", "// TODO: two"}
+	assert.Len(t, items, len(expected))
+	for i, item := range items {
+		line := item.(*LineItem)
+		assert.Equal(t, expected[i], line.ToString())
+		assert.Equal(t, int64(i+1), line.Number)
+		assert.Equal(t, ctx.ContextItem, line.File)
+	}
+
+	cases := []struct {
+		expr   string
+		result []string
+	}{
+		{"*[line::*[contains(., 'TODO')]]", []string{"a.go"}},
+		{"a.go/line::*[starts-with(., '//')]/line-number()", []string{"2", "5"}},
+		{"*/line::*[1]", []string{"package main", "package b"}},
+		{"b.go/line::* = 'package b'", []string{"true"}},
+		{"count(c/line::*)", []string{"0"}},
+	}
+	for _, c := range cases {
+		tree := assertParses(t, c.expr)
+		ctx := MockDefaultContext()
+		ctx.Axes["child"] = &ChildAxis{}
+		ctx.Axes["line"] = &LineAxis{}
+		ctx.CurrentAxis = ctx.Axes["child"]
+		ctx.ContextItem, err = newFileItem(ctx, dir)
+		assert.Nil(t, err)
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, c.expr)
+		assertFileNames(t, ctx, seq, c.result...)
+	}
+}
+
+func TestDeclaredFunctions(t *testing.T) {
+	cases := []struct {
+		expr   string
+		result []Item
+	}{
+		{"declare function double($x) { $x * 2 }; double(21)", []Item{newIntegerItem(42)}},
+		{"declare function pair($a, $b) { $b, $a }; pair(1, (2, 3))",
+			[]Item{newIntegerItem(2), newIntegerItem(3), newIntegerItem(1)}},
+		{"declare function fact($n) { if ($n le 1) then 1 else $n * fact($n - 1) }; fact(5)",
+			[]Item{newIntegerItem(120)}},
+		// Functions may call those declared after them.
+		{"declare function a() { b() + 1 }; declare function b() { 1 }; a()", []Item{newIntegerItem(2)}},
+		// Results are lazy, so the parameters must stay bound as they're read.
+		{"declare function up-to($n) { for $i in 1 to $n return $i * $n }; up-to(3)[last()]",
+			[]Item{newIntegerItem(9)}},
+		{"declare function me() { name() }; me()", []Item{newStringItem("MockedDir")}},
+		{"declare function g() { $global }; let $global := 1 return g()", nil},
+		{"declare function f($x) { $x }; let $x := 1 return f(2) + $x", []Item{newIntegerItem(3)}},
+		{"declare function f($x) { $x }; f()", nil},
+		{"declare function f($x) { $x }; declare function f($y) { $y }; 1", nil},
+		{"declare function f($x, $x) { $x }; 1", nil},
+		{"declare function count($x) { 1 }; 1", nil},
+		// Recursion which never ends is an error, whether the calls are made
+		// as the body is evaluated or as its result is read.
+		{"declare function f($x) { f($x) }; f(1)", nil},
+		{"declare function f($x) { for $i in $x return f($i) }; f(1)", nil},
+		{"declare function down($n) { if ($n le 0) then 0 else down($n - 1) }; down(500)",
+			[]Item{newIntegerItem(0)}},
+	}
+	for _, c := range cases {
+		ctx := MockDefaultContext()
+		items, err := evaluateAll(ctx, c.expr)
+		if c.result == nil {
+			assert.NotNil(t, err, c.expr)
+		} else {
+			assert.Nil(t, err, c.expr)
+			assert.Equal(t, c.result, items, c.expr)
+		}
+		// The functions are only in scope for the query that declares them.
+		_, ok := ctx.Namespace["f"]
+		assert.False(t, ok)
+	}
+
+	// Variables bound before the query (like those from the command line) can
+	// be used by functions.
+	ctx := MockDefaultContext()
+	ctx.Variables["limit"] = []Item{newIntegerItem(10)}
+	items, err := evaluateAll(ctx, "declare function over($x) { $x > $limit }; over(11)")
+	assert.Nil(t, err)
+	assert.Equal(t, []Item{newBooleanItem(true)}, items)
+}
+
+func TestKeywordNamedFiles(t *testing.T) {
+	ctx := mapFSContext(t, fstest.MapFS{
+		"in/a":      {Data: []byte("a\n")},
+		"some/x":    {Data: []byte("x\n")},
+		"for/if/to": {Data: []byte("to\n")},
+	})
+	cases := map[string][]string{
+		"./in/*":                          {"/in/a"},
+		"./some/x":                        {"/some/x"},
+		"for/if/to":                       {"/for/if/to"},
+		"for $in in in/* return $in":      {"/in/a"},
+		"some/x union in/a":               {"/in/a", "/some/x"},
+		"if (some) then some/x else in/a": {"/some/x"},
+		"every $f in in/* satisfies file-contains($f, 'a')": {"true"},
+	}
+	for expr, expected := range cases {
+		items, err := evaluateAll(ctx, expr)
+		assert.Nil(t, err, expr)
+		var actual []string
+		for _, item := range items {
+			if file, ok := item.(*FileItem); ok {
+				actual = append(actual, file.Path)
+			} else {
+				actual = append(actual, item.ToString())
+			}
+		}
+		assert.Equal(t, expected, actual, expr)
+	}
+}
+
+func TestRecentlyModified(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dpath")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	now := time.Now()
+	for name, age := range map[string]time.Duration{
+		"old.txt":    30 * 24 * time.Hour,
+		"week.txt":   7*24*time.Hour + time.Hour,
+		"recent.txt": 6 * 24 * time.Hour,
+		"new.txt":    time.Minute,
+	} {
+		p := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(p, []byte(name), 0644))
+		assert.Nil(t, os.Chtimes(p, now, now.Add(-age)))
+	}
+
+	ctx := DefaultContext()
+	root, err := FileAt(ctx, dir)
+	assert.Nil(t, err)
+	cases := map[string][]string{
+		"*[@mtime > current-dateTime() - duration('P7D')]":        {"new.txt", "recent.txt"},
+		"*[@mtime <= current-dateTime() - duration('P7D')]":       {"old.txt", "week.txt"},
+		"*[current-dateTime() - @mtime > duration('P29D')]":       {"old.txt"},
+		"*[@mtime > dateTime('1970-01-02T00:00:00Z')][@size = 7]": {"new.txt", "old.txt"},
+	}
+	for expr, expected := range cases {
+		q, err := Compile(expr)
+		assert.Nil(t, err, expr)
+		it, err := q.Eval(ctx, root)
+		assert.Nil(t, err, expr)
+		assertResults(t, it, expected...)
+	}
+}
+
+func TestAttributeMultiplication(t *testing.T) {
+	ctx := mapFSContext(t, fstest.MapFS{
+		"c/h.txt": {Data: []byte("h\n")},
+	})
+	cases := map[string]string{
+		"c/h.txt/@size*2":                     "4",
+		"c/h.txt/@size * 2":                   "4",
+		"c/h.txt/@size*(1 + 2)":               "6",
+		"let $n := 3 return c/h.txt/@size*$n": "6",
+		"c/h.txt[@size*2 = 4]/name()":         "h.txt",
+		"count(c/h.txt[@size*2 = 5])":         "0",
+	}
+	for expr, expected := range cases {
+		items, err := evaluateAll(ctx, expr)
+		assert.Nil(t, err, expr)
+		if assert.Len(t, items, 1, expr) {
+			assert.Equal(t, expected, items[0].ToString(), expr)
+		}
+	}
+}
+
+func TestDotNames(t *testing.T) {
+	ctx := mapFSContext(t, fstest.MapFS{
+		".git/config": {Data: []byte("[core]\n")},
+		".hidden1":    {Data: []byte("1\n")},
+		".bashrc":     {Data: []byte("rc\n")},
+		"x":           {Data: []byte("x\n")},
+	})
+	cases := map[string][]string{
+		// Names starting with a dot are name tests, globs or not.
+		"./.git/config": {"/.git/config"},
+		".git/config":   {"/.git/config"},
+		"./.hidden*":    {"/.hidden1"},
+		"./.*rc":        {"/.bashrc"},
+		"./.*":          {"/.bashrc", "/.git", "/.hidden1"},
+		// Elsewhere, the context item may be followed by an operator.
+		"(1 to 3)[.*2 gt 4]":      {"3"},
+		"(1 to 3)[.* 2 gt 4]":     {"3"},
+		"for $n in 3 return .*$n": nil,
+		"(1 to 3)[.eq 2]":         {"2"},
+	}
+	for expr, expected := range cases {
+		items, err := evaluateAll(ctx, expr)
+		if expected == nil {
+			// The context item is a directory, which can't be multiplied.
+			assert.NotNil(t, err, expr)
+			continue
+		}
+		assert.Nil(t, err, expr)
+		var actual []string
+		for _, item := range items {
+			if file, ok := item.(*FileItem); ok {
+				actual = append(actual, file.Path)
+			} else {
+				actual = append(actual, item.ToString())
+			}
+		}
+		assert.Equal(t, expected, actual, expr)
+	}
+}
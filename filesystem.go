@@ -0,0 +1,236 @@
+/*
+filesystem.go contains the functions which access files. They all go through
+the fs.FS of the context, so that DPath can query any kind of file system: the
+real one, an embedded one, or an in-memory one like fstest.MapFS.
+*/
+
+package dpath
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+/*
+The file system used when the context doesn't have one: the real file system,
+starting from its root.
+*/
+var rootFS = os.DirFS("/")
+
+/*
+A file system which can describe symbolic links (the same methods as
+fs.ReadLinkFS, which os.DirFS and fstest.MapFS both have). Symbolic links in a
+file system without these methods can't be told apart from what they link to.
+*/
+type linkFS interface {
+	fs.FS
+	Lstat(name string) (fs.FileInfo, error)
+	ReadLink(name string) (string, error)
+}
+
+/*
+The most symbolic links that will be followed while resolving one path, like
+the limit of the operating system.
+*/
+const maxLinks = 40
+
+/*
+Return the file system of a context.
+*/
+func fileSystem(ctx *Context) fs.FS {
+	if ctx.FS == nil {
+		return rootFS
+	}
+	return ctx.FS
+}
+
+/*
+Return the name of a file within a file system. Files in DPath have absolute
+paths, like /a/b, while names in an fs.FS are relative to its root, like a/b.
+The root itself is ".".
+*/
+func fsName(p string) string {
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+/*
+The FileInfo of a symbolic link in a file system which can't Lstat, where all
+we have is the info of what it links to. It describes a link, not a directory,
+so that links are only followed when the context says to.
+*/
+type symlinkInfo struct {
+	os.FileInfo
+}
+
+func (i *symlinkInfo) Mode() os.FileMode {
+	return os.ModeSymlink | i.FileInfo.Mode().Perm()
+}
+
+func (i *symlinkInfo) IsDir() bool {
+	return false
+}
+
+/*
+Return the FileInfo of an entry of a directory listing. The entry's type comes
+from the listing itself, so it says whether the entry is a link even when its
+info has followed the link.
+*/
+func entryInfo(entry fs.DirEntry) (os.FileInfo, error) {
+	info, err := entry.Info()
+	if err != nil {
+		return nil, err
+	}
+	if entry.Type()&os.ModeSymlink != 0 && info.Mode()&os.ModeSymlink == 0 {
+		return &symlinkInfo{info}, nil
+	}
+	return info, nil
+}
+
+/*
+Return the FileInfo of a file. If the file is a symbolic link, this describes
+the link rather than what it links to. In a file system which can't Lstat, the
+file is looked up in the listing of its directory instead, which still tells us
+whether it is a link.
+*/
+func lstat(ctx *Context, p string) (os.FileInfo, error) {
+	fsys := fileSystem(ctx)
+	name := fsName(p)
+	if lfs, ok := fsys.(linkFS); ok {
+		return lfs.Lstat(name)
+	}
+	if name != "." {
+		if entries, err := fs.ReadDir(fsys, path.Dir(name)); err == nil {
+			base := path.Base(name)
+			i := sort.Search(len(entries), func(i int) bool {
+				return entries[i].Name() >= base
+			})
+			if i < len(entries) && entries[i].Name() == base {
+				return entryInfo(entries[i])
+			}
+		}
+	}
+	return fs.Stat(fsys, name)
+}
+
+/*
+Return the FileInfo of a file, following symbolic links.
+*/
+func stat(ctx *Context, p string) (os.FileInfo, error) {
+	return fs.Stat(fileSystem(ctx), fsName(p))
+}
+
+/*
+Return the FileInfo of each file in a directory, in document order (sorted by
+name). Files which are deleted while the directory is being read are left out.
+When only some of the files are needed, keep filters them by name, so that the
+others aren't looked at.
+*/
+func readDir(ctx *Context, p string, keep func(name string) bool) ([]os.FileInfo, error) {
+	entries, err := fs.ReadDir(fileSystem(ctx), fsName(p))
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if keep != nil && !keep(entry.Name()) {
+			continue
+		}
+		if info, err := entryInfo(entry); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+/*
+Open a file for reading.
+*/
+func openFile(ctx *Context, p string) (fs.File, error) {
+	return fileSystem(ctx).Open(fsName(p))
+}
+
+/*
+Return a file as an io.ReaderAt along with its size, which is what reading a
+zip file needs. Files which can't read at an offset are read into memory.
+*/
+func readerAt(f fs.File) (io.ReaderAt, int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if r, ok := f.(io.ReaderAt); ok {
+		return r, info.Size(), nil
+	}
+	contents, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(contents), int64(len(contents)), nil
+}
+
+/*
+Return true if links can be told apart from what they link to in the file
+system of a context (see linkFS).
+*/
+func hasLinks(ctx *Context) bool {
+	_, ok := fileSystem(ctx).(linkFS)
+	return ok
+}
+
+/*
+Return the path of a file with every symbolic link in it resolved, like
+filepath.EvalSymlinks(). The file must exist.
+*/
+func evalSymlinks(ctx *Context, p string) (string, error) {
+	lfs, ok := fileSystem(ctx).(linkFS)
+	if !ok {
+		// There are no links that we can see, so the path is already resolved.
+		_, err := stat(ctx, p)
+		return path.Clean("/" + p), err
+	}
+	resolved := "/"
+	rest := strings.Split(p, "/")
+	links := 0
+	for len(rest) > 0 {
+		name := rest[0]
+		rest = rest[1:]
+		if name == "." || name == "" {
+			continue
+		} else if name == ".." {
+			resolved = path.Dir(resolved)
+			continue
+		}
+		next := path.Join(resolved, name)
+		info, err := lfs.Lstat(fsName(next))
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+		if links++; links > maxLinks {
+			return "", errors.New("too many levels of symbolic links: " + p)
+		}
+		target, err := lfs.ReadLink(fsName(next))
+		if err != nil {
+			return "", err
+		}
+		if path.IsAbs(target) {
+			resolved = "/"
+		}
+		rest = append(strings.Split(target, "/"), rest...)
+	}
+	return resolved, nil
+}
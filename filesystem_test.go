@@ -0,0 +1,247 @@
+package dpath
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+/*
+Return a context whose file system is an in-memory tree, with real axes.
+*/
+func mapFSContext(t *testing.T, fsys fstest.MapFS) *Context {
+	ctx := MockDefaultContext()
+	ctx.FS = fsys
+	ctx.Axes["child"] = &ChildAxis{}
+	ctx.Axes["parent"] = &ParentAxis{}
+	ctx.Axes["descendant-or-self"] = &DescendantOrSelfAxis{}
+	ctx.Axes["attribute"] = &AttributeAxis{}
+	ctx.Axes["link-target"] = &LinkTargetAxis{}
+	ctx.Axes["line"] = &LineAxis{}
+	ctx.Axes["content"] = &ContentAxis{}
+	ctx.CurrentAxis = ctx.Axes["child"]
+	root, err := newFileItem(ctx, "/")
+	assert.Nil(t, err)
+	ctx.ContextItem = root
+	return ctx
+}
+
+func TestFSName(t *testing.T) {
+	assert.Equal(t, ".", fsName("/"))
+	assert.Equal(t, ".", fsName(""))
+	assert.Equal(t, "a/b", fsName("/a/b"))
+	assert.Equal(t, "a", fsName("/a/b/.."))
+}
+
+func TestMapFS(t *testing.T) {
+	zipped := &bytes.Buffer{}
+	w := zip.NewWriter(zipped)
+	_, err := w.Create("lib/util.go")
+	assert.Nil(t, err)
+	assert.Nil(t, w.Close())
+
+	fsys := fstest.MapFS{
+		"src/main.go":    {Data: []byte("package main\n// TODO\n")},
+		"src/util.go":    {Data: []byte("package main\n")},
+		"src/conf.json":  {Data: []byte(`{"name": "dpath", "tags": ["a", "b"]}`)},
+		"src/vendor.zip": {Data: zipped.Bytes()},
+		"docs":           {Mode: fs.ModeDir},
+		"latest":         {Data: []byte("src"), Mode: fs.ModeSymlink},
+		"src/loop":       {Data: []byte(".."), Mode: fs.ModeSymlink},
+	}
+	cases := []struct {
+		expr   string
+		follow bool
+		values []string
+	}{
+		{"*", false, []string{"docs", "latest", "src"}},
+		{".//*.go", false, []string{"main.go", "util.go"}},
+		{"src/*.go[@size > 14]", false, []string{"main.go"}},
+		{"src/vendor.zip/../util.go/..", false, []string{"src"}},
+		{"src/main.go/line::*[contains(., 'TODO')]", false, []string{"// TODO"}},
+		{"src/conf.json/content::tags/*", false, []string{"a", "b"}},
+		{"latest/*", false, []string{}},
+		{"latest/*.go", true, []string{"main.go", "util.go"}},
+		{"latest/link-target::*", false, []string{"src"}},
+		{"src/loop/link-target::*/path()", false, []string{"/"}},
+		// The loops lead back to the start, so only their children are listed,
+		// and not their descendants.
+		{"count(.//*)", false, []string{"8"}},
+		{"count(.//*)", true, []string{"19"}},
+		{"count(.//loop/*)", true, []string{"6"}},
+	}
+	for _, c := range cases {
+		tree := assertParses(t, c.expr)
+		ctx := mapFSContext(t, fsys)
+		ctx.FollowLinks = c.follow
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, c.expr)
+		assertFileNames(t, ctx, seq, c.values...)
+	}
+
+	ctx := mapFSContext(t, fsys)
+	ctx.Archives = true
+	seq, err := assertParses(t, "src/vendor.zip//*").Evaluate(ctx)
+	assert.Nil(t, err)
+	assertFilePaths(t, ctx, seq, "/src/vendor.zip/lib", "/src/vendor.zip/lib/util.go")
+}
+
+func TestEvalSymlinks(t *testing.T) {
+	ctx := MockDefaultContext()
+	ctx.FS = fstest.MapFS{
+		"a/b/c":  {Data: []byte("")},
+		"link":   {Data: []byte("a/b"), Mode: fs.ModeSymlink},
+		"abs":    {Data: []byte("/link/c"), Mode: fs.ModeSymlink},
+		"self":   {Data: []byte("self"), Mode: fs.ModeSymlink},
+		"broken": {Data: []byte("nowhere"), Mode: fs.ModeSymlink},
+	}
+	resolved, err := evalSymlinks(ctx, "/link/c")
+	assert.Nil(t, err)
+	assert.Equal(t, "/a/b/c", resolved)
+	resolved, err = evalSymlinks(ctx, "/abs")
+	assert.Nil(t, err)
+	assert.Equal(t, "/a/b/c", resolved)
+	resolved, err = evalSymlinks(ctx, "/link/../b")
+	assert.Nil(t, err)
+	assert.Equal(t, "/a/b", resolved)
+	_, err = evalSymlinks(ctx, "/self")
+	assert.NotNil(t, err)
+	_, err = evalSymlinks(ctx, "/broken")
+	assert.NotNil(t, err)
+}
+
+/*
+A file system which can only open files, so it can't Lstat or read links.
+*/
+type openOnlyFS struct {
+	fsys fs.FS
+}
+
+func (o openOnlyFS) Open(name string) (fs.File, error) {
+	return o.fsys.Open(name)
+}
+
+func TestLinksWithoutLstat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dpath")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "a/b"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "a/b/f"), nil, 0644))
+	assert.Nil(t, os.Symlink("..", filepath.Join(dir, "a/b/loop")))
+	assert.Nil(t, os.Symlink("b", filepath.Join(dir, "a/link")))
+
+	for _, fsys := range []fs.FS{os.DirFS(dir), openOnlyFS{os.DirFS(dir)}} {
+		ctx := mapFSContext(t, nil)
+		ctx.FS = fsys
+		ctx.Axes["descendant"] = &DescendantAxis{}
+		ctx.ContextItem, err = newFileItem(ctx, "/")
+		assert.Nil(t, err)
+		link, err := newFileItem(ctx, "/a/link")
+		assert.Nil(t, err)
+		assert.True(t, isSymlink(link))
+
+		// Links are only followed when asked, and cycles are found by the
+		// device and inode numbers of directories.
+		seq, err := assertParses(t, "descendant::*").Evaluate(ctx)
+		assert.Nil(t, err)
+		assertFilePaths(t, ctx, seq, "/a", "/a/b", "/a/b/f", "/a/b/loop", "/a/link")
+		ctx.FollowLinks = true
+		seq, err = assertParses(t, "descendant::*").Evaluate(ctx)
+		assert.Nil(t, err)
+		assertFilePaths(t, ctx, seq, "/a", "/a/b", "/a/b/f", "/a/b/loop",
+			"/a/link", "/a/link/f", "/a/link/loop")
+	}
+
+	// Without Lstat or inode numbers, links can't be followed safely at all.
+	ctx := mapFSContext(t, nil)
+	ctx.Axes["descendant"] = &DescendantAxis{}
+	ctx.FS = openOnlyFS{fstest.MapFS{
+		"a/f":    {Data: []byte("")},
+		"a/loop": {Data: []byte(".."), Mode: fs.ModeSymlink},
+	}}
+	ctx.ContextItem, err = newFileItem(ctx, "/")
+	assert.Nil(t, err)
+	ctx.FollowLinks = true
+	seq, err := assertParses(t, "descendant::*").Evaluate(ctx)
+	assert.Nil(t, err)
+	assertFilePaths(t, ctx, seq, "/a", "/a/f", "/a/loop")
+}
+
+/*
+A file system whose files can only be read in order, which counts how many
+times files are opened and closed, and how much is read from them.
+*/
+type countingFS struct {
+	fsys                fs.FS
+	opens, closes, read int
+}
+
+type countingFile struct {
+	fs.File
+	counts *countingFS
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	f, err := c.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	c.opens++
+	return &countingFile{f, c}, nil
+}
+
+func (f *countingFile) Read(b []byte) (int, error) {
+	n, err := f.File.Read(b)
+	f.counts.read += n
+	return n, err
+}
+
+// Like files on disk, files of a MapFS can seek.
+func (f *countingFile) Seek(offset int64, whence int) (int64, error) {
+	return f.File.(io.Seeker).Seek(offset, whence)
+}
+
+func (f *countingFile) Close() error {
+	f.counts.closes++
+	return f.File.Close()
+}
+
+func TestLinesReadOnce(t *testing.T) {
+	big := strings.Repeat("a line of text\n", 20000) + "needle\n"
+	fsys := &countingFS{fsys: fstest.MapFS{"big.txt": {Data: []byte(big)}}}
+	ctx := mapFSContext(t, nil)
+	ctx.FS = fsys
+	items, err := evaluateAll(ctx, "count(big.txt/line::*)")
+	assert.Nil(t, err)
+	assert.Equal(t, []Item{newIntegerItem(20001)}, items)
+	// The file is read once from start to end, not again for each block.
+	assert.Equal(t, len(big), fsys.read)
+	assert.Equal(t, fsys.opens, fsys.closes)
+
+	// Searches which stop early still close the file.
+	items, err = evaluateAll(ctx, "file-contains(big.txt, 'line')")
+	assert.Nil(t, err)
+	assert.Equal(t, []Item{newBooleanItem(true)}, items)
+	assert.Equal(t, fsys.opens, fsys.closes)
+
+	// So do predicates which stop at the first line they need.
+	items, err = evaluateAll(ctx, "count(big.txt[line::*[contains(., 'line')]])")
+	assert.Nil(t, err)
+	assert.Equal(t, []Item{newIntegerItem(1)}, items)
+	assert.Equal(t, fsys.opens, fsys.closes)
+
+	// Files which can't be read are an error, not a file without lines.
+	file, err := FileAt(ctx, "/big.txt")
+	assert.Nil(t, err)
+	delete(fsys.fsys.(fstest.MapFS), "big.txt")
+	_, err = newLineSequence(file).Next(ctx)
+	assert.NotNil(t, err)
+}
@@ -4,16 +4,20 @@ the DPath data model. Since much of arithmetic is type-specific, most of the
 arithmetic evaluation is implemented in this file.
 */
 
-package main
+package dpath
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"path"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -22,6 +26,11 @@ const (
 	TYPE_BOOLEAN = "boolean"
 	TYPE_STRING  = "string"
 	TYPE_FILE    = "file"
+	TYPE_ATTR    = "attribute"
+	TYPE_TIME    = "dateTime"
+	TYPE_DUR     = "duration"
+	TYPE_LINE    = "line"
+	TYPE_NODE    = "node"
 )
 
 /*
@@ -189,6 +198,8 @@ func (i *IntegerItem) EvalMultiply(right Item) (Sequence, error) {
 		return newSingletonSequence(newIntegerItem(i.Value * getInteger(right))), nil
 	case TYPE_DOUBLE:
 		return newSingletonSequence(newDoubleItem(float64(i.Value) * getDouble(right))), nil
+	case TYPE_DUR:
+		return right.EvalMultiply(i)
 	default:
 		return nil, incomparableError(i, right)
 	}
@@ -297,6 +308,9 @@ func (i *DoubleItem) EvalMinus(right Item) (Sequence, error) {
 }
 
 func (i *DoubleItem) EvalMultiply(right Item) (Sequence, error) {
+	if right.TypeName() == TYPE_DUR {
+		return right.EvalMultiply(i)
+	}
 	if right.TypeName() != TYPE_INTEGER && right.TypeName() != TYPE_DOUBLE {
 		return nil, incomparableError(i, right)
 	}
@@ -432,12 +446,305 @@ func (i *BooleanItem) ToString() string {
 }
 
 /*
-File item (could be a directory too)!
+An Item containing a date and time, such as the modification time of a file.
+*/
+type DateTimeItem struct {
+	*BaseItem
+	Value time.Time
+}
+
+func (i *DateTimeItem) TypeName() string { return TYPE_TIME }
+
+func (i *DateTimeItem) RelativeCompare() bool { return true }
+
+func (i *DateTimeItem) Print(w io.Writer) error {
+	_, err := io.WriteString(w, "dateTime:"+i.ToString()+"\n")
+	return err
+}
+
+func (i *DateTimeItem) Compare(right Item) (int64, error) {
+	if right.TypeName() != TYPE_TIME {
+		return int64(0), incomparableError(i, right)
+	}
+	rightTime := right.(*DateTimeItem).Value
+	if i.Value.Equal(rightTime) {
+		return int64(0), nil
+	} else if i.Value.Before(rightTime) {
+		return int64(-1), nil
+	} else {
+		return int64(1), nil
+	}
+}
+
+func (i *DateTimeItem) EvalPlus(right Item) (Sequence, error) {
+	if right.TypeName() != TYPE_DUR {
+		return unsupported("+", TYPE_TIME, right)
+	}
+	dur := right.(*DurationItem)
+	value := addMonths(i.Value, dur.Months).Add(dur.Value)
+	return newSingletonSequence(newDateTimeItem(value)), nil
+}
+
+/*
+Add months to a time. Unlike time.AddDate(), if the day of the month is past the
+end of the new month, this uses the last day of the new month, so that one month
+after January 31st is the end of February rather than some day in March.
+*/
+func addMonths(t time.Time, months int64) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(),
+		t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+func (i *DateTimeItem) EvalMinus(right Item) (Sequence, error) {
+	switch right.TypeName() {
+	case TYPE_DUR:
+		dur := right.(*DurationItem)
+		return i.EvalPlus(newDurationItem(-dur.Months, -dur.Value))
+	case TYPE_TIME:
+		value := i.Value.Sub(right.(*DateTimeItem).Value)
+		return newSingletonSequence(newDurationItem(0, value)), nil
+	default:
+		return unsupported("-", TYPE_TIME, right)
+	}
+}
+
+func (i *DateTimeItem) ToString() string {
+	return i.Value.Format(time.RFC3339Nano)
+}
+
+func newDateTimeItem(v time.Time) *DateTimeItem {
+	return &DateTimeItem{Value: v}
+}
+
+/*
+Layouts accepted when parsing a dateTime from a string. The ones without a time
+zone are in local time.
+*/
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+/*
+Parse a dateTime from a string, like "2016-04-01T12:00:00Z" or "2016-04-01".
+*/
+func parseDateTime(str string) (*DateTimeItem, error) {
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+			return newDateTimeItem(t), nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintf("invalid dateTime: \"%s\"", str))
+}
+
+/*
+An Item containing a duration, which is a number of months plus an exact amount
+of time. Months are kept separate since they vary in length. Both parts must
+have the same sign.
+*/
+type DurationItem struct {
+	*BaseItem
+	Months int64
+	Value  time.Duration
+}
+
+func (i *DurationItem) TypeName() string { return TYPE_DUR }
+
+func (i *DurationItem) RelativeCompare() bool { return true }
+
+func (i *DurationItem) Print(w io.Writer) error {
+	_, err := io.WriteString(w, "duration:"+i.ToString()+"\n")
+	return err
+}
+
+/*
+Durations can only be compared when one of their parts is equal, since the
+length of a month isn't fixed. For example, P1M and P30D aren't comparable.
+*/
+func (i *DurationItem) Compare(right Item) (int64, error) {
+	if right.TypeName() != TYPE_DUR {
+		return int64(0), incomparableError(i, right)
+	}
+	rightDur := right.(*DurationItem)
+	if i.Months == rightDur.Months {
+		return compareInt64(int64(i.Value), int64(rightDur.Value)), nil
+	} else if i.Value == rightDur.Value {
+		return compareInt64(i.Months, rightDur.Months), nil
+	}
+	return int64(0), errors.New(fmt.Sprintf(
+		"durations %s and %s are not comparable", i.ToString(), rightDur.ToString(),
+	))
+}
+
+func (i *DurationItem) EvalPlus(right Item) (Sequence, error) {
+	switch right.TypeName() {
+	case TYPE_DUR:
+		rightDur := right.(*DurationItem)
+		return checkedDuration(i.Months+rightDur.Months, i.Value+rightDur.Value)
+	case TYPE_TIME:
+		return right.EvalPlus(i)
+	default:
+		return unsupported("+", TYPE_DUR, right)
+	}
+}
+
+func (i *DurationItem) EvalMinus(right Item) (Sequence, error) {
+	if right.TypeName() != TYPE_DUR {
+		return unsupported("-", TYPE_DUR, right)
+	}
+	rightDur := right.(*DurationItem)
+	return checkedDuration(i.Months-rightDur.Months, i.Value-rightDur.Value)
+}
+
+func (i *DurationItem) EvalMultiply(right Item) (Sequence, error) {
+	if right.TypeName() != TYPE_INTEGER && right.TypeName() != TYPE_DOUBLE {
+		return unsupported("*", TYPE_DUR, right)
+	}
+	factor := getNumericAsFloat(right)
+	months := int64(math.Floor(float64(i.Months)*factor + 0.5))
+	value := time.Duration(float64(i.Value) * factor)
+	return newSingletonSequence(newDurationItem(months, value)), nil
+}
+
+func (i *DurationItem) EvalDivide(right Item) (Sequence, error) {
+	switch right.TypeName() {
+	case TYPE_INTEGER, TYPE_DOUBLE:
+		divisor := getNumericAsFloat(right)
+		if divisor == 0 {
+			return nil, errors.New(fmt.Sprintf("cannot divide duration %s by zero", i.ToString()))
+		}
+		return i.EvalMultiply(newDoubleItem(1 / divisor))
+	case TYPE_DUR:
+		rightDur := right.(*DurationItem)
+		if rightDur.Months == 0 && rightDur.Value == 0 {
+			return nil, errors.New(fmt.Sprintf("cannot divide duration %s by zero", i.ToString()))
+		} else if i.Months == 0 && rightDur.Months == 0 {
+			return newSingletonSequence(newDoubleItem(float64(i.Value) / float64(rightDur.Value))), nil
+		} else if i.Value == 0 && rightDur.Value == 0 {
+			return newSingletonSequence(newDoubleItem(float64(i.Months) / float64(rightDur.Months))), nil
+		}
+		return nil, errors.New(fmt.Sprintf(
+			"cannot divide duration %s by %s", i.ToString(), rightDur.ToString(),
+		))
+	default:
+		return unsupported("div", TYPE_DUR, right)
+	}
+}
+
+/*
+Format the duration in the ISO 8601 format, e.g. P1Y2M3DT4H5M6.5S.
+*/
+func (i *DurationItem) ToString() string {
+	if i.Months == 0 && i.Value == 0 {
+		return "PT0S"
+	}
+	var buf bytes.Buffer
+	months, value := i.Months, i.Value
+	if months < 0 || value < 0 {
+		buf.WriteString("-")
+		months, value = -months, -value
+	}
+	buf.WriteString("P")
+	writePart := func(n int64, unit string) {
+		if n != 0 {
+			buf.WriteString(strconv.FormatInt(n, 10) + unit)
+		}
+	}
+	writePart(months/12, "Y")
+	writePart(months%12, "M")
+	days := int64(value / (24 * time.Hour))
+	value -= time.Duration(days) * 24 * time.Hour
+	writePart(days, "D")
+	if value != 0 {
+		buf.WriteString("T")
+		writePart(int64(value/time.Hour), "H")
+		writePart(int64(value%time.Hour/time.Minute), "M")
+		if seconds := value % time.Minute; seconds != 0 {
+			buf.WriteString(strconv.FormatFloat(seconds.Seconds(), 'f', -1, 64) + "S")
+		}
+	}
+	return buf.String()
+}
+
+func newDurationItem(months int64, value time.Duration) *DurationItem {
+	return &DurationItem{Months: months, Value: value}
+}
+
+/*
+Return a sequence containing a new duration, or an error if its parts have
+different signs (like one month minus one day).
+*/
+func checkedDuration(months int64, value time.Duration) (Sequence, error) {
+	if (months < 0 && value > 0) || (months > 0 && value < 0) {
+		return nil, errors.New("duration would have months and days of different signs")
+	}
+	return newSingletonSequence(newDurationItem(months, value)), nil
+}
+
+var durationRegexp = regexp.MustCompile(
+	`^(-)?P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d*)?)S)?)?$`,
+)
+
+/*
+Parse an ISO 8601 duration, like "P7D" or "-PT1H30M".
+*/
+func parseDuration(str string) (*DurationItem, error) {
+	invalid := errors.New(fmt.Sprintf("invalid duration: \"%s\"", str))
+	match := durationRegexp.FindStringSubmatch(str)
+	if match == nil || strings.HasSuffix(str, "P") || strings.HasSuffix(str, "T") {
+		return nil, invalid
+	}
+	parts := make([]int64, 5)
+	for i := range parts {
+		if match[i+2] == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(match[i+2], 10, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		parts[i] = n
+	}
+	seconds := 0.0
+	if match[7] != "" {
+		seconds, _ = strconv.ParseFloat(match[7], 64)
+	}
+	months := parts[0]*12 + parts[1]
+	value := time.Duration(parts[2])*24*time.Hour + time.Duration(parts[3])*time.Hour +
+		time.Duration(parts[4])*time.Minute + time.Duration(seconds*float64(time.Second))
+	if match[1] != "" {
+		months, value = -months, -value
+	}
+	return newDurationItem(months, value), nil
+}
+
+/*
+Compare two integers, returning -1, 0, or 1.
+*/
+func compareInt64(left, right int64) int64 {
+	if left < right {
+		return int64(-1)
+	} else if left > right {
+		return int64(1)
+	}
+	return int64(0)
+}
+
+/*
+File item (could be a directory too)! Files within archives have the index of
+their archive (see archiveIndex), and nil otherwise.
 */
 type FileItem struct {
 	*BaseItem
-	Path string
-	Info os.FileInfo
+	Path    string
+	Info    os.FileInfo
+	Archive *archiveIndex
 }
 
 func (i *FileItem) TypeName() string { return TYPE_FILE }
@@ -464,8 +771,8 @@ func (i *FileItem) ToString() string {
 	return i.Info.Name()
 }
 
-func newFileItem(absPath string) (*FileItem, error) {
-	info, err := os.Lstat(absPath)
+func newFileItem(ctx *Context, absPath string) (*FileItem, error) {
+	info, err := lstat(ctx, absPath)
 	if err != nil {
 		return nil, err
 	}
@@ -477,21 +784,164 @@ func newFileItemFromInfo(info os.FileInfo, parent string) *FileItem {
 	return &FileItem{Path: absPath, Info: info}
 }
 
+/*
+Attribute item, which is a named value from the attribute axis, like the
+modification time of a file. All operations on it apply to its value.
+*/
+type AttributeItem struct {
+	Item
+	Name string
+}
+
+func (i *AttributeItem) TypeName() string { return TYPE_ATTR }
+
+func (i *AttributeItem) Print(w io.Writer) error {
+	_, err := io.WriteString(w, "attribute:"+i.Name+"="+i.Item.ToString()+"\n")
+	return err
+}
+
+func newAttributeItem(name string, value Item) *AttributeItem {
+	return &AttributeItem{Item: value, Name: name}
+}
+
+/*
+Line item, which is a line of text from a file on the line axis. Like an
+attribute, all operations on it apply to its text. Lines are numbered from one.
+*/
+type LineItem struct {
+	Item
+	File   *FileItem
+	Number int64
+}
+
+func (i *LineItem) TypeName() string { return TYPE_LINE }
+
+func (i *LineItem) Print(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "line:%s:%d:%s\n", i.File.Path, i.Number, i.Item.ToString())
+	return err
+}
+
+func newLineItem(file *FileItem, number int64, text string) *LineItem {
+	return &LineItem{Item: newStringItem(text), File: file, Number: number}
+}
+
+/*
+Node item, which is part of a structured document (like a JSON, YAML or XML
+file) found on the content axis. A node has a name (an object key or element
+name, or "" for an array element), and possibly some children and attributes.
+Operations on a node apply to its value: scalars have their own value, and
+other nodes have the text of all their descendants, as a string.
+
+Top-level nodes have no Parent, since their parent is the file. Position is the
+position of a node among its siblings, starting at one.
+*/
+type NodeItem struct {
+	Item
+	File       *FileItem
+	Name       string
+	Parent     *NodeItem
+	Position   int
+	Children   []*NodeItem
+	Attributes []*AttributeItem
+}
+
+func (i *NodeItem) TypeName() string { return TYPE_NODE }
+
+func (i *NodeItem) Print(w io.Writer) error {
+	str := "node:" + i.File.Path + ":" + i.NodePath()
+	if len(i.Children) == 0 {
+		str += "=" + i.Item.ToString()
+	}
+	_, err := io.WriteString(w, str+"\n")
+	return err
+}
+
+/*
+Return the path to a node within its document, like /dependencies/lodash. Nodes
+without names are shown by their position, like /files/*[2].
+*/
+func (i *NodeItem) NodePath() string {
+	step := i.Name
+	if step == "" {
+		step = fmt.Sprintf("*[%d]", i.Position)
+	}
+	if i.Parent == nil {
+		return "/" + step
+	}
+	return i.Parent.NodePath() + "/" + step
+}
+
+/*
+Return a node with a scalar value.
+*/
+func newScalarNode(name string, value Item) *NodeItem {
+	return &NodeItem{Item: value, Name: name}
+}
+
+/*
+Return a node containing others, whose value is the text of its children.
+*/
+func newContainerNode(name string, children []*NodeItem) *NodeItem {
+	var text bytes.Buffer
+	for _, child := range children {
+		text.WriteString(child.ToString())
+	}
+	return &NodeItem{Item: newStringItem(text.String()), Name: name, Children: children}
+}
+
+/*
+Return the value of an attribute, line or node item, or any other item
+unchanged. Operators should use this on their operands, since an attribute on
+the right hand side would otherwise have the wrong type.
+*/
+func atomize(i Item) Item {
+	switch i := i.(type) {
+	case *AttributeItem:
+		return i.Item
+	case *LineItem:
+		return i.Item
+	case *NodeItem:
+		return i.Item
+	}
+	return i
+}
+
+/*
+Compare two files by document order, returning a negative number if left comes
+first, zero if they are the same file, and a positive number if right comes
+first. Document order is a pre-order traversal of the file system, where the
+contents of each directory are ordered lexicographically by name. So, paths are
+compared one component at a time, and a directory comes before its contents.
+*/
+func documentOrder(left, right *FileItem) int {
+	leftParts := strings.Split(strings.Trim(left.Path, "/"), "/")
+	rightParts := strings.Split(strings.Trim(right.Path, "/"), "/")
+	for i := 0; i < len(leftParts) && i < len(rightParts); i++ {
+		if cmp := strings.Compare(leftParts[i], rightParts[i]); cmp != 0 {
+			return cmp
+		}
+	}
+	return len(leftParts) - len(rightParts)
+}
+
 /*
 Value comparison functions!
 */
 
 func CmpEq(left, right Item) (bool, error) {
+	left, right = atomize(left), atomize(right)
 	res, err := left.Compare(right)
 	return res == 0, err
 }
 
 func CmpNe(left, right Item) (bool, error) {
+	left, right = atomize(left), atomize(right)
 	res, err := left.Compare(right)
 	return res != 0, err
 }
 
 func CmpLe(left, right Item) (bool, error) {
+	left, right = atomize(left), atomize(right)
 	if !left.RelativeCompare() || !right.RelativeCompare() {
 		return false, noRelCmpError(left, right)
 	}
@@ -500,6 +950,7 @@ func CmpLe(left, right Item) (bool, error) {
 }
 
 func CmpLt(left, right Item) (bool, error) {
+	left, right = atomize(left), atomize(right)
 	if !left.RelativeCompare() || !right.RelativeCompare() {
 		return false, noRelCmpError(left, right)
 	}
@@ -508,6 +959,7 @@ func CmpLt(left, right Item) (bool, error) {
 }
 
 func CmpGe(left, right Item) (bool, error) {
+	left, right = atomize(left), atomize(right)
 	if !left.RelativeCompare() || !right.RelativeCompare() {
 		return false, noRelCmpError(left, right)
 	}
@@ -516,6 +968,7 @@ func CmpGe(left, right Item) (bool, error) {
 }
 
 func CmpGt(left, right Item) (bool, error) {
+	left, right = atomize(left), atomize(right)
 	if !left.RelativeCompare() || !right.RelativeCompare() {
 		return false, noRelCmpError(left, right)
 	}
@@ -1,4 +1,4 @@
-package main
+package dpath
 
 import (
 	"github.com/stretchr/testify/assert"
@@ -22,6 +22,69 @@ func TestNumeric(t *testing.T) {
 	assert.Equal(t, l.Lex(&sym), eof)
 }
 
+func TestSizeLiteral(t *testing.T) {
+	var sym yySymType
+	uut := "100MB 4KiB 1.5G .5Ki 12B 1kB 2E 1.5e3"
+	l := NewLexer(strings.NewReader(uut))
+	for _, text := range strings.Fields(uut)[:7] {
+		assert.Equal(t, l.Lex(&sym), SIZE_LITERAL)
+		assert.Equal(t, text, sym.str)
+	}
+	assert.Equal(t, l.Lex(&sym), DOUBLE_LITERAL)
+	assert.Equal(t, l.Lex(&sym), eof)
+}
+
+func TestGlob(t *testing.T) {
+	var sym yySymType
+	uut := "*.go test_* [a-c]*.txt file?.[!o]* .* *[x]y"
+	l := NewLexer(strings.NewReader(uut))
+	for _, text := range strings.Fields(uut) {
+		assert.Equal(t, l.Lex(&sym), GLOB)
+		assert.Equal(t, text, sym.str)
+	}
+	assert.Equal(t, l.Lex(&sym), eof)
+
+	// Neither of these are globs, but predicates. (The trailing space ends the
+	// last token, see Parse().)
+	uut = "*[1] a[b] "
+	l = NewLexer(strings.NewReader(uut))
+	assert.Equal(t, l.Lex(&sym), MULTIPLY)
+	assert.Equal(t, l.Lex(&sym), LBRACKET)
+	assert.Equal(t, l.Lex(&sym), INTEGER_LITERAL)
+	assert.Equal(t, l.Lex(&sym), RBRACKET)
+	assert.Equal(t, l.Lex(&sym), QNAME)
+	assert.Equal(t, l.Lex(&sym), LBRACKET)
+	assert.Equal(t, l.Lex(&sym), QNAME)
+	assert.Equal(t, l.Lex(&sym), RBRACKET)
+	assert.Equal(t, l.Lex(&sym), eof)
+}
+
+func TestGlobLexer(t *testing.T) {
+	cases := map[string][]int{
+		"*.go":       {GLOB},
+		"a/*.go":     {QNAME, SLASH, GLOB},
+		"2*3":        {INTEGER_LITERAL, MULTIPLY, INTEGER_LITERAL},
+		"(a)*b*":     {LPAREN, QNAME, RPAREN, MULTIPLY, GLOB},
+		"$x*2":       {DOLLAR, QNAME, MULTIPLY, INTEGER_LITERAL},
+		"$abc*def*g": {DOLLAR, QNAME, MULTIPLY, GLOB},
+		"x[1]*.go":   {QNAME, LBRACKET, INTEGER_LITERAL, RBRACKET, MULTIPLY, QNAME},
+		".*2":        {DOT, MULTIPLY, INTEGER_LITERAL},
+		".* $x":      {DOT, MULTIPLY, DOLLAR, QNAME},
+		"[.eq 1]":    {LBRACKET, DOT, VEQ, INTEGER_LITERAL, RBRACKET},
+		"./.*2":      {DOT, SLASH, GLOB},
+		"(.*)":       {LPAREN, GLOB, RPAREN},
+		".git/.h*":   {QNAME, SLASH, GLOB},
+	}
+	for uut, tokens := range cases {
+		var sym yySymType
+		l := newGlobLexer(NewLexer(strings.NewReader(uut + "\n")))
+		for _, tok := range tokens {
+			assert.Equal(t, tok, l.Lex(&sym), uut)
+		}
+		assert.Equal(t, eof, l.Lex(&sym), uut)
+	}
+}
+
 func TestString(t *testing.T) {
 	var sym yySymType
 	uut := "'abc' 'abc''def' \"abc\" \"abc\"\"def\""
@@ -35,7 +98,7 @@ func TestString(t *testing.T) {
 
 func TestKeywords(t *testing.T) {
 	var sym yySymType
-	uut := "or and idiv div mod eq ne lt le gt ge file dir to"
+	uut := "or and idiv div mod eq ne lt le gt ge file dir to let return for in if then else some every satisfies union intersect except"
 	l := NewLexer(strings.NewReader(uut))
 	assert.Equal(t, l.Lex(&sym), OR)
 	assert.Equal(t, l.Lex(&sym), AND)
@@ -51,20 +114,35 @@ func TestKeywords(t *testing.T) {
 	assert.Equal(t, l.Lex(&sym), FILE)
 	assert.Equal(t, l.Lex(&sym), DIR)
 	assert.Equal(t, l.Lex(&sym), TO)
+	assert.Equal(t, l.Lex(&sym), LET)
+	assert.Equal(t, l.Lex(&sym), RETURN)
+	assert.Equal(t, l.Lex(&sym), FOR)
+	assert.Equal(t, l.Lex(&sym), IN)
+	assert.Equal(t, l.Lex(&sym), IF)
+	assert.Equal(t, l.Lex(&sym), THEN)
+	assert.Equal(t, l.Lex(&sym), ELSE)
+	assert.Equal(t, l.Lex(&sym), SOME)
+	assert.Equal(t, l.Lex(&sym), EVERY)
+	assert.Equal(t, l.Lex(&sym), SATISFIES)
+	assert.Equal(t, l.Lex(&sym), UNION)
+	assert.Equal(t, l.Lex(&sym), INTERSECT)
+	assert.Equal(t, l.Lex(&sym), EXCEPT)
 	assert.Equal(t, l.Lex(&sym), eof)
 }
 
 func TestSymbols(t *testing.T) {
 	var sym yySymType
-	uut := ":: $ ( ) [ ] , + - * / = != < <= > >= @ .. ."
+	uut := ":: := $ ( ) [ ] , | + - * / = != < <= > >= @ .. ."
 	l := NewLexer(strings.NewReader(uut))
 	assert.Equal(t, l.Lex(&sym), AXIS)
+	assert.Equal(t, l.Lex(&sym), ASSIGN)
 	assert.Equal(t, l.Lex(&sym), DOLLAR)
 	assert.Equal(t, l.Lex(&sym), LPAREN)
 	assert.Equal(t, l.Lex(&sym), RPAREN)
 	assert.Equal(t, l.Lex(&sym), LBRACKET)
 	assert.Equal(t, l.Lex(&sym), RBRACKET)
 	assert.Equal(t, l.Lex(&sym), COMMA)
+	assert.Equal(t, l.Lex(&sym), UNION)
 	assert.Equal(t, l.Lex(&sym), PLUS)
 	assert.Equal(t, l.Lex(&sym), MINUS)
 	assert.Equal(t, l.Lex(&sym), MULTIPLY)
@@ -97,3 +175,55 @@ func TestInvalidNames(t *testing.T) {
 	assertDoesNotLex(t, "0abc", QNAME)
 	assertDoesNotLex(t, "-abc", QNAME)
 }
+
+func TestDeclareFunction(t *testing.T) {
+	var sym yySymType
+	uut := "declare function f($x) { $x }; declare\n\tfunction declare"
+	l := NewLexer(strings.NewReader(uut + "\n"))
+	for _, tok := range []int{
+		DECLARE_FUNCTION, QNAME, LPAREN, DOLLAR, QNAME, RPAREN,
+		LBRACE, DOLLAR, QNAME, RBRACE, SEMICOLON, DECLARE_FUNCTION, QNAME,
+	} {
+		assert.Equal(t, tok, l.Lex(&sym))
+	}
+	assert.Equal(t, "declare", sym.str)
+	assert.Equal(t, l.Lex(&sym), eof)
+}
+
+func TestKeywordVariableNames(t *testing.T) {
+	var sym yySymType
+	l := newGlobLexer(NewLexer(strings.NewReader("$file in $in div $div*2\n")))
+	for _, tok := range []int{DOLLAR, QNAME, IN, DOLLAR, QNAME, DIVIDE, DOLLAR, QNAME, MULTIPLY, INTEGER_LITERAL} {
+		assert.Equal(t, tok, l.Lex(&sym))
+	}
+	assert.Equal(t, "2", sym.str)
+	assert.Equal(t, l.Lex(&sym), eof)
+}
+
+func TestComments(t *testing.T) {
+	var sym yySymType
+	uut, err := stripComments("(: files :) *.go (: with a :: or two\n:) [size(.) (::)]")
+	assert.Nil(t, err)
+	l := NewLexer(strings.NewReader(uut + "\n"))
+	for _, tok := range []int{GLOB, LBRACKET, QNAME, LPAREN, DOT, RPAREN, RBRACKET} {
+		assert.Equal(t, tok, l.Lex(&sym))
+	}
+	assert.Equal(t, l.Lex(&sym), eof)
+}
+
+func TestNestedComments(t *testing.T) {
+	var sym yySymType
+	uut, err := stripComments("(: outer (: inner :) it's :) a(: x :)b \"(: not a comment :)\"")
+	assert.Nil(t, err)
+	l := NewLexer(strings.NewReader(uut + "\n"))
+	for _, tok := range []int{QNAME, QNAME, STRING_LITERAL} {
+		assert.Equal(t, tok, l.Lex(&sym))
+	}
+	assert.Equal(t, "\"(: not a comment :)\"", sym.str)
+	assert.Equal(t, l.Lex(&sym), eof)
+
+	for _, s := range []string{"(: outer (: inner :) 1", "(: 1", "1 (:)"} {
+		_, err := stripComments(s)
+		assert.NotNil(t, err, s)
+	}
+}
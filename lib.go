@@ -3,7 +3,7 @@ lib.go contains built-in functions of the DPath language, along with any
 utilities necessary to run them.
 */
 
-package main
+package dpath
 
 import (
 	"bytes"
@@ -12,54 +12,145 @@ import (
 	"math"
 	"regexp"
 	"strings"
+	"time"
 )
 
 /*
 Builtin specifies the interface that all builtin functions must satisfy.
+
+Params describes each argument, and a call may leave off the ones after the
+first MinArgs. When MaxArgs is -1, the function takes any number of arguments,
+and the last parameter describes all of the extra ones. Calls are checked
+against this signature before the function is invoked (see execBuiltin()), and
+Returns and Doc describe the function for documentation (see Signature()).
 */
 type Builtin struct {
 	Name    string
-	NumArgs int
+	Params  []Param
+	MinArgs int
+	MaxArgs int
+	Returns string
+	Doc     string
 	Invoke  func(ctx *Context, args ...Sequence) (Sequence, error)
 }
 
 var (
 	BUILTIN_BOOLEAN = Builtin{
-		Name: "boolean", NumArgs: 1, Invoke: BuiltinBooleanInvoke}
+		Name: "boolean", Params: []Param{{"arg", "item", "*"}}, MinArgs: 1, MaxArgs: 1,
+		Returns: "boolean", Invoke: BuiltinBooleanInvoke,
+		Doc: "The effective boolean value of a sequence: false if it is empty, true if it starts with a file or other node, or else the truth of its single value."}
 	BUILTIN_CONCAT = Builtin{
-		Name: "concat", NumArgs: -1, Invoke: BuiltinConcatInvoke}
+		Name: "concat", Params: []Param{{"value", "item", ""}}, MinArgs: 1, MaxArgs: -1,
+		Returns: "string", Invoke: BuiltinConcatInvoke,
+		Doc: "Join the string values of its arguments."}
 	BUILTIN_ROUND = Builtin{
-		Name: "round", NumArgs: 1, Invoke: BuiltinRoundInvoke}
+		Name: "round", Params: []Param{{"number", "numeric", ""}}, MinArgs: 1, MaxArgs: 1,
+		Returns: "numeric", Invoke: BuiltinRoundInvoke,
+		Doc: "Round a number to the nearest integer, rounding halves up."}
 	BUILTIN_SUBSTRING = Builtin{
-		Name: "substring", NumArgs: -1, Invoke: BuiltinSubstringInvoke}
+		Name: "substring", Params: []Param{{"string", "string", "?"}, {"start", "numeric", ""}, {"length", "numeric", ""}},
+		MinArgs: 2, MaxArgs: 3, Returns: "string", Invoke: BuiltinSubstringInvoke,
+		Doc: "The part of a string from a position (counting from 1), up to the end or for a length."}
 	BUILTIN_STRING = Builtin{
-		Name: "string", NumArgs: -1, Invoke: BuiltinStringInvoke}
+		Name: "string", Params: []Param{{"arg", "item", "?"}}, MinArgs: 0, MaxArgs: 1,
+		Returns: "string", Invoke: BuiltinStringInvoke,
+		Doc: "The string value of an item, or of the context item."}
 	BUILTIN_STRING_LENGTH = Builtin{
-		Name: "string-length", NumArgs: -1, Invoke: BuiltinStringLengthInvoke}
+		Name: "string-length", Params: []Param{{"string", "string", "?"}}, MinArgs: 0, MaxArgs: 1,
+		Returns: "integer", Invoke: BuiltinStringLengthInvoke,
+		Doc: "The length of a string, or of the string value of the context item."}
 	BUILTIN_ENDS_WITH = Builtin{
-		Name: "ends-with", NumArgs: 2, Invoke: BuiltinEndsWithInvoke}
+		Name: "ends-with", Params: []Param{{"string", "string", "?"}, {"suffix", "string", "?"}}, MinArgs: 2, MaxArgs: 2,
+		Returns: "boolean", Invoke: BuiltinEndsWithInvoke,
+		Doc: "True if a string ends with a suffix."}
 	BUILTIN_STARTS_WITH = Builtin{
-		Name: "starts-with", NumArgs: 2, Invoke: BuiltinStartsWithInvoke}
+		Name: "starts-with", Params: []Param{{"string", "string", "?"}, {"prefix", "string", "?"}}, MinArgs: 2, MaxArgs: 2,
+		Returns: "boolean", Invoke: BuiltinStartsWithInvoke,
+		Doc: "True if a string starts with a prefix."}
 	BUILTIN_CONTAINS = Builtin{
-		Name: "contains", NumArgs: 2, Invoke: BuiltinContainsInvoke}
+		Name: "contains", Params: []Param{{"string", "string", "?"}, {"part", "string", "?"}}, MinArgs: 2, MaxArgs: 2,
+		Returns: "boolean", Invoke: BuiltinContainsInvoke,
+		Doc: "True if a string contains another."}
 	BUILTIN_MATCHES = Builtin{
-		Name: "matches", NumArgs: 2, Invoke: BuiltinMatchesInvoke}
+		Name: "matches", Params: []Param{{"string", "string", "?"}, {"regex", "string", "?"}}, MinArgs: 2, MaxArgs: 2,
+		Returns: "boolean", Invoke: BuiltinMatchesInvoke,
+		Doc: "True if the whole of a string matches a regular expression."}
 	BUILTIN_EMPTY = Builtin{
-		Name: "empty", NumArgs: 1, Invoke: BuiltinEmptyInvoke}
+		Name: "empty", Params: []Param{{"arg", "item", "*"}}, MinArgs: 1, MaxArgs: 1,
+		Returns: "boolean", Invoke: BuiltinEmptyInvoke,
+		Doc: "True if a sequence is empty."}
 	BUILTIN_EXISTS = Builtin{
-		Name: "exists", NumArgs: 1, Invoke: BuiltinExistsInvoke}
+		Name: "exists", Params: []Param{{"arg", "item", "*"}}, MinArgs: 1, MaxArgs: 1,
+		Returns: "boolean", Invoke: BuiltinExistsInvoke,
+		Doc: "True if a sequence is not empty."}
 	BUILTIN_NAME = Builtin{
-		Name: "name", NumArgs: -1, Invoke: BuiltinNameInvoke}
+		Name: "name", Params: []Param{{"node", "item", ""}}, MinArgs: 0, MaxArgs: 1,
+		Returns: "string", Invoke: BuiltinNameInvoke,
+		Doc: "The name of a file, attribute or document node, or of the context item."}
 	BUILTIN_PATH = Builtin{
-		Name: "path", NumArgs: -1, Invoke: BuiltinPathInvoke}
+		Name: "path", Params: []Param{{"file", "file", ""}}, MinArgs: 0, MaxArgs: 1,
+		Returns: "string", Invoke: BuiltinPathInvoke,
+		Doc: "The absolute path of a file, or of the context item."}
 	BUILTIN_COUNT = Builtin{
-		Name: "count", NumArgs: 1, Invoke: BuiltinCountInvoke}
+		Name: "count", Params: []Param{{"arg", "item", "*"}}, MinArgs: 1, MaxArgs: 1,
+		Returns: "integer", Invoke: BuiltinCountInvoke,
+		Doc: "The number of items in a sequence."}
 	BUILTIN_TRUE = Builtin{
-		Name: "true", NumArgs: 0, Invoke: BuiltinTrueInvoke}
+		Name: "true", MinArgs: 0, MaxArgs: 0,
+		Returns: "boolean", Invoke: BuiltinTrueInvoke,
+		Doc: "The boolean true."}
 	BUILTIN_FALSE = Builtin{
-		Name: "false", NumArgs: 0, Invoke: BuiltinFalseInvoke}
+		Name: "false", MinArgs: 0, MaxArgs: 0,
+		Returns: "boolean", Invoke: BuiltinFalseInvoke,
+		Doc: "The boolean false."}
 	BUILTIN_NOT = Builtin{
-		Name: "not", NumArgs: 1, Invoke: BuiltinNotInvoke}
+		Name: "not", Params: []Param{{"arg", "item", "*"}}, MinArgs: 1, MaxArgs: 1,
+		Returns: "boolean", Invoke: BuiltinNotInvoke,
+		Doc: "The opposite of the effective boolean value of a sequence."}
+	BUILTIN_POSITION = Builtin{
+		Name: "position", MinArgs: 0, MaxArgs: 0,
+		Returns: "integer", Invoke: BuiltinPositionInvoke,
+		Doc: "The position of the context item within the sequence being filtered."}
+	BUILTIN_LAST = Builtin{
+		Name: "last", MinArgs: 0, MaxArgs: 0,
+		Returns: "integer", Invoke: BuiltinLastInvoke,
+		Doc: "The size of the sequence being filtered."}
+	BUILTIN_CURRENT_DATETIME = Builtin{
+		Name: "current-dateTime", MinArgs: 0, MaxArgs: 0,
+		Returns: "dateTime", Invoke: BuiltinCurrentDateTimeInvoke,
+		Doc: "The current date and time."}
+	BUILTIN_DATETIME = Builtin{
+		Name: "dateTime", Params: []Param{{"value", "item", "?"}}, MinArgs: 1, MaxArgs: 1,
+		Returns: "dateTime", Invoke: BuiltinDateTimeInvoke,
+		Doc: "Parse a date and time, like '2017-04-01T12:00:00Z' or '2017-04-01'."}
+	BUILTIN_DURATION = Builtin{
+		Name: "duration", Params: []Param{{"value", "item", "?"}}, MinArgs: 1, MaxArgs: 1,
+		Returns: "duration", Invoke: BuiltinDurationInvoke,
+		Doc: "Parse an ISO 8601 duration, like 'P7D' or 'PT1H30M'."}
+	BUILTIN_FORMAT_DATETIME = Builtin{
+		Name: "format-dateTime", Params: []Param{{"value", "dateTime", ""}, {"picture", "string", "?"}}, MinArgs: 2, MaxArgs: 2,
+		Returns: "string", Invoke: BuiltinFormatDateTimeInvoke,
+		Doc: "Format a date and time using a picture string, like '[Y0001]-[M01]-[D01]'."}
+	BUILTIN_FORMAT_SIZE = Builtin{
+		Name: "format-size", Params: []Param{{"bytes", "numeric", ""}, {"units", "string", "?"}}, MinArgs: 1, MaxArgs: 2,
+		Returns: "string", Invoke: BuiltinFormatSizeInvoke,
+		Doc: "Format a number of bytes for people to read, like '1.5 MiB', using powers of 1000 when units is 'si'."}
+	BUILTIN_LINE_NUMBER = Builtin{
+		Name: "line-number", Params: []Param{{"line", "line", ""}}, MinArgs: 0, MaxArgs: 1,
+		Returns: "integer", Invoke: BuiltinLineNumberInvoke,
+		Doc: "The number of a line from the line axis, or of the context item."}
+	BUILTIN_FILE_CONTAINS = Builtin{
+		Name: "file-contains", Params: []Param{{"file", "file", ""}, {"text", "string", "?"}}, MinArgs: 2, MaxArgs: 2,
+		Returns: "boolean", Invoke: BuiltinFileContainsInvoke,
+		Doc: "True if any line of a text file contains a string."}
+	BUILTIN_FILE_MATCHES = Builtin{
+		Name: "file-matches", Params: []Param{{"file", "file", ""}, {"regex", "string", "?"}}, MinArgs: 2, MaxArgs: 2,
+		Returns: "boolean", Invoke: BuiltinFileMatchesInvoke,
+		Doc: "True if any line of a text file has a match for a regular expression."}
+	BUILTIN_GREP = Builtin{
+		Name: "grep", Params: []Param{{"file", "file", ""}, {"regex", "string", "?"}}, MinArgs: 2, MaxArgs: 2,
+		Returns: "line*", Invoke: BuiltinGrepInvoke,
+		Doc: "The lines of a text file which have a match for a regular expression."}
 )
 
 /*
@@ -69,7 +160,7 @@ The steps are summarized by this logic, found in the specification.
 https://www.w3.org/TR/xpath20/#id-ebv
 
 1. Empty sequence -> false.
-2. First item of sequence is node (file) -> true.
+2. First item of sequence is node (file, attribute, line or document node) -> true.
 3. Singleton of type boolean -> value.
 4. Singleton of string type -> false if zero length
 5. Singleton of numeric type -> false if zero or NaN
@@ -93,6 +184,11 @@ func BuiltinBooleanInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
 	}
 
 	item := arg.Value()
+	switch item.TypeName() {
+	case TYPE_FILE, TYPE_ATTR, TYPE_LINE, TYPE_NODE:
+		// Case 2, true! (Attributes, lines and document nodes are nodes too.)
+		return newSingletonSequence(newBooleanItem(true)), nil
+	}
 
 	hasNext, err = arg.Next(ctx)
 	if err != nil {
@@ -100,13 +196,8 @@ func BuiltinBooleanInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
 	}
 	if hasNext {
 		// NOT A SINGLETON
-		if item.TypeName() == TYPE_FILE {
-			// Case 2, true!
-			value = true
-		} else {
-			// Case 6
-			return nil, errors.New("type error in boolean(): sequence of non-file")
-		}
+		// Case 6
+		return nil, errors.New("type error in boolean(): sequence of non-file")
 	} else {
 		// SINGLETON
 		switch item.TypeName() {
@@ -161,6 +252,7 @@ func BuiltinRoundInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
 	if err != nil {
 		return nil, err
 	}
+	item = atomize(item)
 	switch item.TypeName() {
 	case TYPE_INTEGER:
 		return newSingletonSequence(item), nil
@@ -353,6 +445,12 @@ func BuiltinNameInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
 	} else {
 		return nil, errors.New("wrong number of arguments to name()")
 	}
+	switch item := item.(type) {
+	case *AttributeItem:
+		return newSingletonSequence(newStringItem(item.Name)), nil
+	case *NodeItem:
+		return newSingletonSequence(newStringItem(item.Name)), nil
+	}
 	if item.TypeName() != TYPE_FILE {
 		return nil, errors.New("name() expects argument of type file)")
 	}
@@ -379,6 +477,104 @@ func BuiltinPathInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
 	return newSingletonSequence(newStringItem(file.Path)), nil
 }
 
+/*
+Return the number of a line from the line axis, either the argument or the
+context item.
+*/
+func BuiltinLineNumberInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
+	var item Item
+	var err error
+	if len(args) == 1 {
+		item, err = getSingleItem(ctx, args[0])
+		if err != nil {
+			return nil, err
+		}
+	} else if len(args) == 0 {
+		item = ctx.ContextItem
+	} else {
+		return nil, errors.New("wrong number of arguments to line-number()")
+	}
+	line, ok := item.(*LineItem)
+	if !ok {
+		return nil, errors.New("line-number() expects argument of type line")
+	}
+	return newSingletonSequence(newIntegerItem(line.Number)), nil
+}
+
+/*
+Return the lines of a file (the first argument) which contain a string, or which
+have a match for a regular expression if regexp is true (the second argument).
+The lines are read and searched as the sequence is advanced, so searching stops
+as soon as the caller has seen enough. Directories, entries of archives and
+binary files (see LineSequence) have no lines, so nothing is found in them.
+*/
+func searchFile(ctx *Context, name string, args []Sequence, regex bool) (Sequence, error) {
+	item, err := getSingleItem(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	file, ok := item.(*FileItem)
+	if !ok {
+		return nil, fmt.Errorf("%s() expects argument of type file", name)
+	}
+	pattern, err := funcGetString(ctx, args[1])
+	if err != nil {
+		return nil, err
+	}
+	match := func(line string) bool { return strings.Contains(line, pattern) }
+	if regex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		match = re.MatchString
+	}
+	if !hasLines(ctx, file) {
+		return newEmptySequence(), nil
+	}
+	return newConditionFilter(newTextLineSequence(file), func(it Item) bool {
+		return match(it.ToString())
+	}), nil
+}
+
+/*
+Return true if searchFile() finds anything, reading no further once it does.
+*/
+func searchFileFinds(ctx *Context, name string, args []Sequence, regex bool) (Sequence, error) {
+	matches, err := searchFile(ctx, name, args, regex)
+	if err != nil {
+		return nil, err
+	}
+	found, err := matches.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newSingletonSequence(newBooleanItem(found)), nil
+}
+
+/*
+Return true if any line of a file contains a string.
+*/
+func BuiltinFileContainsInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
+	return searchFileFinds(ctx, "file-contains", args, false)
+}
+
+/*
+Return true if any line of a file has a match for a regular expression. Unlike
+matches(), the expression doesn't need to match the whole line.
+*/
+func BuiltinFileMatchesInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
+	return searchFileFinds(ctx, "file-matches", args, true)
+}
+
+/*
+Return the lines of a file which have a match for a regular expression, as line
+items.
+*/
+func BuiltinGrepInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
+	return searchFile(ctx, "grep", args, true)
+}
+
 func BuiltinCountInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
 	var n bool
 	var e error
@@ -410,27 +606,289 @@ func BuiltinNotInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
 }
 
 /*
-Return a map of each builtin's name to its struct.
+Invoke the builtin "position" function, which returns the position of the
+context item within the sequence being filtered by a predicate.
+*/
+func BuiltinPositionInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
+	return newSingletonSequence(newIntegerItem(ctx.ContextPosition)), nil
+}
+
+/*
+Invoke the builtin "last" function, which returns the size of the sequence
+being filtered by a predicate (i.e. the position of the last item).
+*/
+func BuiltinLastInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
+	size, err := ctx.ContextSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newSingletonSequence(newIntegerItem(size)), nil
+}
+
+/*
+Invoke the builtin "current-dateTime" function, which returns the current time.
+*/
+func BuiltinCurrentDateTimeInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
+	return newSingletonSequence(newDateTimeItem(time.Now())), nil
+}
+
+/*
+Invoke the builtin "dateTime" function, which parses a string into a dateTime.
+*/
+func BuiltinDateTimeInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
+	str, err := coerceGetString(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	item, err := parseDateTime(str)
+	if err != nil {
+		return nil, err
+	}
+	return newSingletonSequence(item), nil
+}
+
+/*
+Invoke the builtin "duration" function, which parses an ISO 8601 duration
+string (like "P7D") into a duration.
+*/
+func BuiltinDurationInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
+	str, err := coerceGetString(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	item, err := parseDuration(str)
+	if err != nil {
+		return nil, err
+	}
+	return newSingletonSequence(item), nil
+}
+
+/*
+Invoke the builtin "format-dateTime" function, which formats a dateTime
+according to a picture string (see formatDateTime()).
+*/
+func BuiltinFormatDateTimeInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
+	item, err := getSingleItem(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	item = atomize(item)
+	if item.TypeName() != TYPE_TIME {
+		return nil, errors.New("format-dateTime() expects a dateTime")
+	}
+	picture, err := funcGetString(ctx, args[1])
+	if err != nil {
+		return nil, err
+	}
+	str, err := formatDateTime(item.(*DateTimeItem).Value, picture)
+	if err != nil {
+		return nil, err
+	}
+	return newSingletonSequence(newStringItem(str)), nil
+}
+
+/*
+Format a time using a subset of the XPath picture string syntax. Each component
+is written in square brackets, like "[Y0001]-[M01]-[D01]", where the letter
+says which component, and the digits after it give the minimum width. Month and
+weekday names may be requested with "N" (upper case), "n" (lower case) or "Nn"
+(title case), like "[FNn], [D] [MNn]". Brackets are escaped by doubling them.
+
+The components are: Y (year), M (month), D (day of month), d (day of year), F
+(day of week), H (hour, 0-23), h (hour, 1-12), P (am/pm), m (minute), s
+(second), f (fractional seconds) and Z (time zone).
+*/
+func formatDateTime(t time.Time, picture string) (string, error) {
+	var buffer bytes.Buffer
+	for len(picture) > 0 {
+		switch {
+		case strings.HasPrefix(picture, "[["):
+			buffer.WriteString("[")
+			picture = picture[2:]
+		case strings.HasPrefix(picture, "]]"):
+			buffer.WriteString("]")
+			picture = picture[2:]
+		case picture[0] == '[':
+			end := strings.Index(picture, "]")
+			if end < 0 {
+				return "", errors.New("unterminated component in picture string")
+			}
+			marker := strings.Join(strings.Fields(picture[1:end]), "")
+			str, err := formatDateTimeComponent(t, marker)
+			if err != nil {
+				return "", err
+			}
+			buffer.WriteString(str)
+			picture = picture[end+1:]
+		default:
+			buffer.WriteByte(picture[0])
+			picture = picture[1:]
+		}
+	}
+	return buffer.String(), nil
+}
+
+/*
+Format one component of a time, given the contents of its square brackets.
+*/
+func formatDateTimeComponent(t time.Time, marker string) (string, error) {
+	if len(marker) == 0 {
+		return "", errors.New("empty component in picture string")
+	}
+	component, modifier := marker[0], marker[1:]
+	if comma := strings.Index(modifier, ","); comma >= 0 {
+		modifier = modifier[:comma]
+	}
+	width := 0
+	for _, c := range modifier {
+		if '0' <= c && c <= '9' {
+			width++
+		}
+	}
+	name := func(str string) string {
+		switch modifier {
+		case "N":
+			return strings.ToUpper(str)
+		case "Nn":
+			return str
+		default:
+			return strings.ToLower(str)
+		}
+	}
+	number := func(n, defaultWidth int) string {
+		if width == 0 {
+			width = defaultWidth
+		}
+		return fmt.Sprintf("%0*d", width, n)
+	}
+	switch component {
+	case 'Y':
+		if width == 2 {
+			return number(t.Year()%100, 1), nil
+		}
+		return number(t.Year(), 1), nil
+	case 'M':
+		if width == 0 && modifier != "" {
+			return name(t.Month().String()), nil
+		}
+		return number(int(t.Month()), 1), nil
+	case 'D':
+		return number(t.Day(), 1), nil
+	case 'd':
+		return number(t.YearDay(), 1), nil
+	case 'F':
+		if width == 0 {
+			return name(t.Weekday().String()), nil
+		}
+		return number(int(t.Weekday()), 1), nil
+	case 'H':
+		return number(t.Hour(), 1), nil
+	case 'h':
+		return number((t.Hour()+11)%12+1, 1), nil
+	case 'P':
+		return name(t.Format("PM")), nil
+	case 'm':
+		return number(t.Minute(), 2), nil
+	case 's':
+		return number(t.Second(), 2), nil
+	case 'f':
+		if width == 0 {
+			width = 1
+		}
+		fraction := fmt.Sprintf("%09d", t.Nanosecond())
+		if width > len(fraction) {
+			width = len(fraction)
+		}
+		return fraction[:width], nil
+	case 'Z':
+		return t.Format("-07:00"), nil
+	default:
+		return "", errors.New(fmt.Sprintf(
+			"unknown component [%s] in picture string", marker,
+		))
+	}
+}
+
+/*
+Invoke the builtin "format-size" function, which formats a number of bytes as
+human readable text. An optional second argument of "si" selects powers of 1000
+rather than powers of 1024.
+*/
+func BuiltinFormatSizeInvoke(ctx *Context, args ...Sequence) (Sequence, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, errors.New("format-size() takes one or two arguments")
+	}
+	item, err := getSingleItem(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	item = atomize(item)
+	if item.TypeName() != TYPE_INTEGER && item.TypeName() != TYPE_DOUBLE {
+		return nil, errors.New("format-size() expects a number")
+	}
+	si := false
+	if len(args) == 2 {
+		units, err := funcGetString(ctx, args[1])
+		if err != nil {
+			return nil, err
+		}
+		switch units {
+		case "si":
+			si = true
+		case "iec":
+			si = false
+		default:
+			return nil, errors.New("format-size() units must be \"si\" or \"iec\"")
+		}
+	}
+	str := formatSize(getNumericAsInteger(item), si)
+	return newSingletonSequence(newStringItem(str)), nil
+}
+
+/*
+The builtins which are in every default namespace, by name. RegisterBuiltin()
+adds more.
+*/
+var registeredBuiltins = map[string]Builtin{
+	"boolean":          BUILTIN_BOOLEAN,
+	"concat":           BUILTIN_CONCAT,
+	"round":            BUILTIN_ROUND,
+	"substring":        BUILTIN_SUBSTRING,
+	"string":           BUILTIN_STRING,
+	"string-length":    BUILTIN_STRING_LENGTH,
+	"ends-with":        BUILTIN_ENDS_WITH,
+	"starts-with":      BUILTIN_STARTS_WITH,
+	"contains":         BUILTIN_CONTAINS,
+	"matches":          BUILTIN_MATCHES,
+	"empty":            BUILTIN_EMPTY,
+	"exists":           BUILTIN_EXISTS,
+	"name":             BUILTIN_NAME,
+	"path":             BUILTIN_PATH,
+	"count":            BUILTIN_COUNT,
+	"true":             BUILTIN_TRUE,
+	"false":            BUILTIN_FALSE,
+	"not":              BUILTIN_NOT,
+	"position":         BUILTIN_POSITION,
+	"last":             BUILTIN_LAST,
+	"current-dateTime": BUILTIN_CURRENT_DATETIME,
+	"dateTime":         BUILTIN_DATETIME,
+	"duration":         BUILTIN_DURATION,
+	"format-dateTime":  BUILTIN_FORMAT_DATETIME,
+	"format-size":      BUILTIN_FORMAT_SIZE,
+	"line-number":      BUILTIN_LINE_NUMBER,
+	"file-contains":    BUILTIN_FILE_CONTAINS,
+	"file-matches":     BUILTIN_FILE_MATCHES,
+	"grep":             BUILTIN_GREP,
+}
+
+/*
+Return a map of each builtin's name to its struct, including the builtins added
+with RegisterBuiltin().
 */
 func DefaultNamespace() map[string]Builtin {
-	return map[string]Builtin{
-		"boolean":       BUILTIN_BOOLEAN,
-		"concat":        BUILTIN_CONCAT,
-		"round":         BUILTIN_ROUND,
-		"substring":     BUILTIN_SUBSTRING,
-		"string":        BUILTIN_STRING,
-		"string-length": BUILTIN_STRING_LENGTH,
-		"ends-with":     BUILTIN_ENDS_WITH,
-		"starts-with":   BUILTIN_STARTS_WITH,
-		"contains":      BUILTIN_CONTAINS,
-		"matches":       BUILTIN_MATCHES,
-		"empty":         BUILTIN_EMPTY,
-		"exists":        BUILTIN_EXISTS,
-		"name":          BUILTIN_NAME,
-		"path":          BUILTIN_PATH,
-		"count":         BUILTIN_COUNT,
-		"true":          BUILTIN_TRUE,
-		"false":         BUILTIN_FALSE,
-		"not":           BUILTIN_NOT,
+	namespace := make(map[string]Builtin, len(registeredBuiltins))
+	for name, builtin := range registeredBuiltins {
+		namespace[name] = builtin
 	}
+	return namespace
 }
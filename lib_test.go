@@ -1,9 +1,14 @@
-package main
+package dpath
 
 import (
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestBooleanEmptySequence(t *testing.T) {
@@ -46,6 +51,23 @@ func TestBooleanNonEmptyString(t *testing.T) {
 	assert.True(t, v.Value)
 }
 
+func TestBooleanNodes(t *testing.T) {
+	ctx := MockDefaultContext()
+	file := MockFile("/MockedDir/a", "a", false)
+	inputs := []Sequence{
+		newSingletonSequence(file),
+		newSingletonSequence(newLineItem(file, 1, "")),
+		newWrapperSequence([]Item{newLineItem(file, 1, ""), newIntegerItem(0)}),
+	}
+	for _, input := range inputs {
+		s, e1 := BuiltinBooleanInvoke(ctx, input)
+		assert.Nil(t, e1)
+		i, e2 := getSingleItem(ctx, s)
+		assert.Nil(t, e2)
+		assert.True(t, getBool(i))
+	}
+}
+
 func TestBooleanIntegerZero(t *testing.T) {
 	ctx := MockDefaultContext()
 	input := newSingletonSequence(newIntegerItem(int64(0)))
@@ -613,3 +635,231 @@ func TestTrueFalseNotInvalid(t *testing.T) {
 		assert.Error(t, err, uut)
 	}
 }
+
+func TestPositionLast(t *testing.T) {
+	cases := []string{
+		"position()",
+		"last()",
+		"(4, 5, 6)[position() eq 2]",
+		"(4, 5, 6)[last()]",
+		"(4, 5, 6)[. eq 5][position() eq last()]",
+	}
+	results := []int64{1, 1, 5, 6, 5}
+	for i, uut := range cases {
+		seq, ctx := assertEvaluates(t, uut)
+		item := assertSingleton(t, ctx, seq)
+		assert.Equal(t, results[i], getInteger(item), uut)
+	}
+}
+
+func TestPositionLastInvalid(t *testing.T) {
+	cases := []string{
+		"position(1)",
+		"last(())",
+	}
+	for _, uut := range cases {
+		tree := assertParses(t, uut)
+		ctx := MockDefaultContext()
+		_, err := tree.Evaluate(ctx)
+		assert.Error(t, err, uut)
+	}
+}
+
+func TestDateTimeDuration(t *testing.T) {
+	cases := map[string]string{
+		"dateTime('2016-04-01T12:00:00Z')":        "2016-04-01T12:00:00Z",
+		"dateTime('2016-04-01T12:00:00.5+02:00')": "2016-04-01T12:00:00.5+02:00",
+		"duration('P1Y2M3DT4H5M6.5S')":            "P1Y2M3DT4H5M6.5S",
+		"duration('-PT90M')":                      "-PT1H30M",
+		"duration('P0D')":                         "PT0S",
+		"dateTime('2016-04-01T12:00:00Z') - dateTime('2016-03-01T00:00:00Z')":       "P31DT12H",
+		"dateTime('2016-04-01T12:00:00Z') - duration('P7D')":                        "2016-03-25T12:00:00Z",
+		"dateTime('2016-01-31T00:00:00Z') + duration('P1M')":                        "2016-02-29T00:00:00Z",
+		"duration('PT1H') + dateTime('2016-04-01T12:00:00Z')":                       "2016-04-01T13:00:00Z",
+		"duration('P1D') - duration('PT1H')":                                        "PT23H",
+		"duration('PT36H') * 2":                                                     "P3D",
+		"0.5 * duration('P1Y')":                                                     "P6M",
+		"duration('P1D') div 4":                                                     "PT6H",
+		"duration('P1D') div duration('PT1H')":                                      "24",
+		"dateTime('2016-04-01T12:00:00Z') < dateTime('2016-04-01T13:00:00+02:00')":  "false",
+		"dateTime('2016-04-01T12:00:00Z') eq dateTime('2016-04-01T14:00:00+02:00')": "true",
+		"duration('P1D') > duration('PT23H')":                                       "true",
+		"duration('P1Y') = duration('P12M')":                                        "true",
+		"string(dateTime('2016-04-01T12:00:00Z'))":                                  "2016-04-01T12:00:00Z",
+	}
+	for uut, expected := range cases {
+		seq, ctx := assertEvaluates(t, uut)
+		item := assertSingleton(t, ctx, seq)
+		assert.Equal(t, expected, item.ToString(), uut)
+	}
+}
+
+func TestDateTimeDurationInvalid(t *testing.T) {
+	cases := []string{
+		"dateTime('yesterday')",
+		"duration('P')",
+		"duration('PT')",
+		"duration('P1H')",
+		"duration('P1M') < duration('P30D')",
+		"duration('P1M') - duration('P1D')",
+		"dateTime('2016-04-01') + 1",
+		"dateTime('2016-04-01') + dateTime('2016-04-01')",
+		"duration('P1D') < 1",
+		"duration('P1D') div 0",
+		"duration('P1M') div 0.0",
+		"duration('P1D') div duration('PT0S')",
+		"duration('P1M') div duration('P0M')",
+		"duration('PT0S') div duration('PT0S')",
+		"format-dateTime(1, '[Y]')",
+		"format-dateTime(current-dateTime(), '[Q]')",
+		"format-dateTime(current-dateTime(), '[Y')",
+	}
+	for _, uut := range cases {
+		tree := assertParses(t, uut)
+		ctx := MockDefaultContext()
+		_, err := tree.Evaluate(ctx)
+		assert.Error(t, err, uut)
+	}
+}
+
+func TestFormatDateTime(t *testing.T) {
+	date := time.Date(2016, time.April, 1, 13, 5, 9, 250000000, time.UTC)
+	cases := map[string]string{
+		"[Y0001]-[M01]-[D01]":         "2016-04-01",
+		"[D]/[M]/[Y01] [H01]:[m]:[s]": "1/4/16 13:05:09",
+		"[h]:[m] [P] [PN]":            "1:05 pm PM",
+		"[FNn], [D] [MNn] [Y]":        "Friday, 1 April 2016",
+		"[F] [Mn] [FN] [d]":           "friday april FRIDAY 92",
+		"[s].[f001][Z] [[literal]]":   "09.250+00:00 [literal]",
+		"no components":               "no components",
+	}
+	for picture, expected := range cases {
+		str, err := formatDateTime(date, picture)
+		assert.Nil(t, err, picture)
+		assert.Equal(t, expected, str, picture)
+	}
+}
+
+func TestSizeLiterals(t *testing.T) {
+	cases := map[string]int64{
+		"100MB":    100000000,
+		"4KiB":     4096,
+		"4Ki":      4096,
+		"1.5G":     1500000000,
+		"1.5GiB":   1610612736,
+		".5k":      500,
+		"12B":      12,
+		"2TB":      2000000000000,
+		"1PiB":     1 << 50,
+		"7EiB":     7 << 60,
+		"9EB":      9000000000000000000,
+		"1KiB + 1": 1025,
+	}
+	for uut, expected := range cases {
+		seq, ctx := assertEvaluates(t, uut)
+		item := assertSingleton(t, ctx, seq)
+		assert.Equal(t, newIntegerItem(expected), item, uut)
+	}
+
+	// Sizes which don't fit in an integer don't parse.
+	for _, uut := range []string{"16EiB", "10EB", "8EiB", "@size lt 16EiB"} {
+		_, err := ParseString(uut)
+		if assert.Error(t, err, uut) {
+			assert.Contains(t, err.Error(), "too large", uut)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	cases := map[string]string{
+		"format-size(0)":              "0 B",
+		"format-size(1023)":           "1023 B",
+		"format-size(1KiB)":           "1 KiB",
+		"format-size(1536)":           "1.5 KiB",
+		"format-size(1.5GiB)":         "1.5 GiB",
+		"format-size(100MB, 'si')":    "100 MB",
+		"format-size(1234567, 'si')":  "1.2 MB",
+		"format-size(1234567, 'iec')": "1.2 MiB",
+		"format-size(-2048)":          "-2 KiB",
+		"format-size(1536.4)":         "1.5 KiB",
+	}
+	for uut, expected := range cases {
+		seq, ctx := assertEvaluates(t, uut)
+		item := assertSingleton(t, ctx, seq)
+		assert.Equal(t, newStringItem(expected), item, uut)
+	}
+}
+
+func TestFormatSizeInvalid(t *testing.T) {
+	cases := []string{
+		"format-size()",
+		"format-size('big')",
+		"format-size((1, 2))",
+		"format-size(1, 'metric')",
+		"format-size(1, 'si', 2)",
+	}
+	for _, uut := range cases {
+		tree := assertParses(t, uut)
+		ctx := MockDefaultContext()
+		_, err := tree.Evaluate(ctx)
+		assert.Error(t, err, uut)
+	}
+}
+
+func TestFileSearch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dpath")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	// The first line is too long, so only the start of it is searched.
+	long := strings.Repeat("x", maxLineLength) + "hidden"
+	files := map[string]string{
+		"text":   long + "\nfunc main() {\n\t// TODO: more\n}\n",
+		"binary": "func\x00main",
+	}
+	ctx := MockDefaultContext()
+	for name, contents := range files {
+		p := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(p, []byte(contents), 0644))
+		file, err := newFileItem(ctx, p)
+		assert.Nil(t, err)
+		ctx.Variables[name] = []Item{file}
+	}
+	dirItem, err := newFileItem(ctx, dir)
+	assert.Nil(t, err)
+	ctx.Variables["folder"] = []Item{dirItem}
+
+	cases := map[string][]string{
+		"file-contains($text, 'TODO')":       {"true"},
+		"file-contains($text, 'todo')":       {"false"},
+		"file-contains($text, 'hidden')":     {"false"},
+		"file-contains($binary, 'main')":     {"false"},
+		"file-contains($folder, 'main')":     {"false"},
+		"file-matches($text, '^func \\w+')":  {"true"},
+		"file-matches($text, '^main')":       {"false"},
+		"grep($text, '[{}]$')":               {"func main() {", "}"},
+		"grep($text, 'TODO')/line-number()":  {"3"},
+		"grep($binary, '.')":                 {},
+		"string-length(grep($text, '^x+$'))": {"1048576"},
+	}
+	for uut, expected := range cases {
+		tree := assertParses(t, uut)
+		seq, err := tree.Evaluate(ctx)
+		assert.Nil(t, err, uut)
+		items, err := seqToSlice(seq, ctx)
+		assert.Nil(t, err, uut)
+		assert.Len(t, items, len(expected), uut)
+		for i, item := range items {
+			assert.Equal(t, expected[i], item.ToString(), uut)
+		}
+	}
+
+	for _, uut := range []string{
+		"file-contains('text', 'TODO')",
+		"file-matches($text, '(')",
+		"grep(($text, $binary), 'x')",
+	} {
+		tree := assertParses(t, uut)
+		_, err := tree.Evaluate(ctx)
+		assert.Error(t, err, uut)
+	}
+}
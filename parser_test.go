@@ -1,4 +1,4 @@
-package main
+package dpath
 
 import (
 	"github.com/stretchr/testify/assert"
@@ -14,6 +14,13 @@ func TestSimpleExpressionsParse(t *testing.T) {
 	assertEmptySequenceTree(t, "()")
 }
 
+func TestCommentsParse(t *testing.T) {
+	assertLiteral(t, "(: outer (: inner :) :) 1")
+	assertLiteral(t, "1 (: with (: another :) inside :)")
+	_, err := ParseString("(: outer (: inner :) 1")
+	assert.NotNil(t, err)
+}
+
 func TestRangeExpressions(t *testing.T) {
 	bt := assertBinop(t, "1 + 1 to 2 + 2")
 	assert.Equal(t, bt.Operator, "to")
@@ -113,3 +120,135 @@ func TestLiteralName(t *testing.T) {
 	pt := root.(*NameTree)
 	assert.Equal(t, pt.Name, "My very long file name.docx")
 }
+
+func TestLetExpressionParses(t *testing.T) {
+	root := assertParses(t, "let $x := 1, $y := $x + 1 return $x * $y")
+	assert.IsType(t, (*LetTree)(nil), root)
+	lt := root.(*LetTree)
+	assert.Equal(t, "x", lt.Variable)
+	assert.IsType(t, (*LiteralTree)(nil), lt.Value)
+	assert.IsType(t, (*LetTree)(nil), lt.Return)
+	inner := lt.Return.(*LetTree)
+	assert.Equal(t, "y", inner.Variable)
+	assert.IsType(t, (*BinopTree)(nil), inner.Value)
+	assert.IsType(t, (*BinopTree)(nil), inner.Return)
+	ret := inner.Return.(*BinopTree)
+	assert.IsType(t, (*VarRefTree)(nil), ret.Left)
+	assert.Equal(t, "x", ret.Left.(*VarRefTree).Name)
+}
+
+func TestForExpressionParses(t *testing.T) {
+	root := assertParses(t, "for $f in //file(), $a in $f/@* return concat(name($f), $a)")
+	assert.IsType(t, (*ForTree)(nil), root)
+	ft := root.(*ForTree)
+	assert.Equal(t, "f", ft.Variable)
+	assert.IsType(t, (*PathTree)(nil), ft.Source)
+	assert.IsType(t, (*ForTree)(nil), ft.Return)
+	inner := ft.Return.(*ForTree)
+	assert.Equal(t, "a", inner.Variable)
+	assert.IsType(t, (*PathTree)(nil), inner.Source)
+	assert.IsType(t, (*FunccallTree)(nil), inner.Return)
+}
+
+func TestIfExpressionParses(t *testing.T) {
+	root := assertParses(t, "if (@size > 5, 1) then 'big' else if (2) then () else 3")
+	assert.IsType(t, (*IfTree)(nil), root)
+	it := root.(*IfTree)
+	assert.IsType(t, (*SequenceTree)(nil), it.Condition)
+	assert.IsType(t, (*LiteralTree)(nil), it.Then)
+	assert.IsType(t, (*IfTree)(nil), it.Else)
+	inner := it.Else.(*IfTree)
+	assert.IsType(t, (*EmptySequenceTree)(nil), inner.Then)
+}
+
+func TestQuantifiedExpressionParses(t *testing.T) {
+	root := assertParses(t, "every $c in ./*, $d in $c/* satisfies $d/@size < 1000")
+	assert.IsType(t, (*QuantifiedTree)(nil), root)
+	qt := root.(*QuantifiedTree)
+	assert.Equal(t, "every", qt.Quantifier)
+	assert.Equal(t, "c", qt.Variable)
+	assert.IsType(t, (*QuantifiedTree)(nil), qt.Condition)
+	inner := qt.Condition.(*QuantifiedTree)
+	assert.Equal(t, "every", inner.Quantifier)
+	assert.Equal(t, "d", inner.Variable)
+	assert.IsType(t, (*BinopTree)(nil), inner.Condition)
+
+	root = assertParses(t, "some $f in .//file() satisfies ends-with(name($f), '.lock')")
+	assert.IsType(t, (*QuantifiedTree)(nil), root)
+	assert.Equal(t, "some", root.(*QuantifiedTree).Quantifier)
+}
+
+func TestSetOperatorsParse(t *testing.T) {
+	bt := assertBinop(t, "a | b intersect c union d except e")
+	assert.Equal(t, "union", bt.Operator)
+	assert.IsType(t, (*BinopTree)(nil), bt.Left)
+	left := bt.Left.(*BinopTree)
+	assert.Equal(t, "union", left.Operator)
+	assert.IsType(t, (*NameTree)(nil), left.Left)
+	assert.IsType(t, (*BinopTree)(nil), left.Right)
+	assert.Equal(t, "intersect", left.Right.(*BinopTree).Operator)
+	assert.IsType(t, (*BinopTree)(nil), bt.Right)
+	assert.Equal(t, "except", bt.Right.(*BinopTree).Operator)
+
+	bt = assertBinop(t, "2 * a | b")
+	assert.Equal(t, "*", bt.Operator)
+	assert.IsType(t, (*BinopTree)(nil), bt.Right)
+	assert.Equal(t, "union", bt.Right.(*BinopTree).Operator)
+}
+
+func TestGlobParses(t *testing.T) {
+	tree := assertParses(t, "//*.go")
+	assert.IsType(t, (*PathTree)(nil), tree)
+	path := tree.(*PathTree).Path
+	assert.Equal(t, &GlobTree{Pattern: "*.go"}, path[len(path)-1])
+
+	tree = assertParses(t, "child::[!._]*")
+	assert.Equal(t, &GlobTree{Pattern: "[^._]*"}, tree.(*AxisTree).Expression)
+
+	bt := assertBinop(t, "count(*)*2")
+	assert.Equal(t, "*", bt.Operator)
+}
+
+func TestFunctionDeclParses(t *testing.T) {
+	tree := assertParses(t, "declare function f() { 1 }; declare function g($a, $b) { $a, $b }; g(f(), 2)")
+	assert.IsType(t, (*ModuleTree)(nil), tree)
+	module := tree.(*ModuleTree)
+	assert.Len(t, module.Functions, 2)
+	assert.Equal(t, "f", module.Functions[0].Name)
+	assert.Equal(t, []string{}, module.Functions[0].Params)
+	assert.Equal(t, "g", module.Functions[1].Name)
+	assert.Equal(t, []string{"a", "b"}, module.Functions[1].Params)
+	assert.IsType(t, (*SequenceTree)(nil), module.Functions[1].Body)
+	assert.IsType(t, (*FunccallTree)(nil), module.Query)
+
+	for _, s := range []string{
+		"declare function f() { 1 } f()",
+		"declare function f() { 1 };",
+		"f(); declare function f() { 1 }",
+		"declare function f($a) {}; f(1)",
+		"declare function f(a) { 1 }; f(1)",
+	} {
+		_, err := ParseString(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestKeywordsAsNames(t *testing.T) {
+	assertQName(t, "in")
+	assertQName(t, "for")
+	root := assertParses(t, "./in")
+	assert.Equal(t, &NameTree{Name: "in"}, root.(*PathTree).Path[1])
+	root = assertParses(t, "./some/x")
+	assert.Equal(t, &NameTree{Name: "some"}, root.(*PathTree).Path[1])
+	for _, uut := range []string{
+		"./if", "./file", "dir/return", "else[then]", "@to", "child::union",
+		"* union in", "for $x in in return $x", "let $let := let return $let",
+		"if (if) then then else else", "some $some in some satisfies satisfies",
+		"1 div div", "a/file()", "./every/@size * 2",
+	} {
+		assertParses(t, uut)
+	}
+	bt := assertBinop(t, "except except except")
+	assert.Equal(t, "except", bt.Operator)
+	assert.Equal(t, &NameTree{Name: "except"}, bt.Left)
+}
@@ -0,0 +1,209 @@
+/*
+Package dpath evaluates DPath expressions, an XPath-like language for querying
+file systems. Compile an expression into a Query, then evaluate it from a root
+file:
+
+	q, err := dpath.Compile(".//*.go[@size > 4KB]")
+	if err != nil {
+		return err
+	}
+	ctx := dpath.DefaultContext()
+	root, err := dpath.FileAt(ctx, "/home/me/src")
+	if err != nil {
+		return err
+	}
+	results, err := q.Eval(ctx, root)
+	if err != nil {
+		return err
+	}
+	for results.Next() {
+		fmt.Println(results.Item().ToString())
+	}
+	return results.Err()
+
+The context decides which file system is queried (it could be an fstest.MapFS,
+say), which axes and functions are available, and which variables are bound.
+*/
+package dpath
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+/*
+A Query is a compiled DPath expression. It can be evaluated any number of times.
+*/
+type Query struct {
+	Expr string
+	Tree ParseTree
+}
+
+/*
+Compile parses a DPath expression into a Query.
+*/
+func Compile(expr string) (*Query, error) {
+	tree, err := ParseString(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Query{Expr: expr, Tree: tree}, nil
+}
+
+/*
+Eval evaluates a query with root as its context item (so that relative paths
+start from root), and returns an iterator over the results. When ctx is nil,
+DefaultContext() is used, and when root is nil, the context item of ctx is.
+Anything else which ctx leaves unset (like its FS or Namespace) is filled in as
+DefaultContext() would, so a Context{} with only some fields set works too.
+
+The context is copied rather than modified. The copy has its own Variables,
+starting with the bindings of ctx, so several queries can be evaluated with the
+same context at once. It shares the Namespace and Axes of ctx, which shouldn't
+be changed until the iterator is done.
+*/
+func (q *Query) Eval(ctx *Context, root Item) (*Iterator, error) {
+	if ctx == nil {
+		ctx = DefaultContext()
+	}
+	evalCtx := *ctx
+	evalCtx.Variables = make(map[string][]Item, len(ctx.Variables))
+	for name, value := range ctx.Variables {
+		evalCtx.Variables[name] = value
+	}
+	if evalCtx.FS == nil {
+		evalCtx.FS = rootFS
+	}
+	if evalCtx.Namespace == nil {
+		evalCtx.Namespace = DefaultNamespace()
+	}
+	if evalCtx.Axes == nil {
+		evalCtx.Axes = DefaultAxes()
+	}
+	if evalCtx.CurrentAxis == nil {
+		evalCtx.CurrentAxis = evalCtx.Axes["child"]
+	}
+	if root == nil && evalCtx.ContextItem == nil {
+		// Start from the current directory, or the root of any other FS.
+		var err error
+		if root, err = FileAt(&evalCtx, "."); err != nil {
+			return nil, err
+		}
+	}
+	if root != nil {
+		evalCtx.ContextItem = root
+		evalCtx.ContextPosition = 1
+		evalCtx.ContextSize = singletonContextSize
+	}
+	if evalCtx.ContextSize == nil {
+		evalCtx.ContextPosition = 1
+		evalCtx.ContextSize = singletonContextSize
+	}
+	seq, err := q.Tree.Evaluate(&evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return &Iterator{Context: &evalCtx, Sequence: seq}, nil
+}
+
+/*
+Iterator steps through the results of a query. Call Next() before each item,
+and check Err() once it returns false, like a bufio.Scanner.
+*/
+type Iterator struct {
+	Context  *Context
+	Sequence Sequence
+	Done     bool
+	Error    error
+}
+
+/*
+Next advances to the next result, returning false when there are none left or
+an error occurred.
+*/
+func (it *Iterator) Next() bool {
+	if it.Done {
+		return false
+	}
+	hasNext, err := it.Sequence.Next(it.Context)
+	if err != nil || !hasNext {
+		it.Done = true
+		it.Error = err
+		return false
+	}
+	return true
+}
+
+/*
+Item returns the current result.
+*/
+func (it *Iterator) Item() Item {
+	return it.Sequence.Value()
+}
+
+/*
+Err returns the error which ended the iteration, if any.
+*/
+func (it *Iterator) Err() error {
+	return it.Error
+}
+
+/*
+FileAt returns the file at a path in the file system of a context, to use as the
+root of a query. In the real file system, a relative path is relative to the
+current directory. In any other, paths are relative to its root (see fsName()).
+*/
+func FileAt(ctx *Context, p string) (*FileItem, error) {
+	if fileSystem(ctx) == rootFS {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		return newFileItem(ctx, abs)
+	}
+	return newFileItem(ctx, path.Clean("/"+p))
+}
+
+/*
+The names which variables may have: the same as a QNAME in the lexer.
+*/
+var variableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.-]*$`)
+
+/*
+BindVariable binds $name in a context to a value given as text, like on a
+command line. The type decides how the text is read: "string" (or "") keeps it
+as it is, "integer" parses it as a number, and "file" finds the file at that
+path with FileAt().
+*/
+func BindVariable(ctx *Context, name, typeName, value string) error {
+	if !variableName.MatchString(name) {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	var item Item
+	switch typeName {
+	case "", TYPE_STRING:
+		item = newStringItem(value)
+	case TYPE_INTEGER:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("variable %s is not an integer: %q", name, value)
+		}
+		item = newIntegerItem(v)
+	case TYPE_FILE:
+		file, err := FileAt(ctx, value)
+		if err != nil {
+			return err
+		}
+		item = file
+	default:
+		return fmt.Errorf("variable %s has unknown type %q", name, typeName)
+	}
+	if ctx.Variables == nil {
+		ctx.Variables = map[string][]Item{}
+	}
+	ctx.Variables[name] = []Item{item}
+	return nil
+}
@@ -0,0 +1,189 @@
+package dpath
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func init() {
+	// The command line driver hides warnings, so hide them in tests too.
+	log.SetLevel(log.ErrorLevel)
+}
+
+/*
+Check the string value of every result of a query.
+*/
+func assertResults(t *testing.T, it *Iterator, expected ...string) {
+	var actual []string
+	for it.Next() {
+		actual = append(actual, it.Item().ToString())
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, expected, actual)
+	assert.False(t, it.Next())
+}
+
+func TestCompile(t *testing.T) {
+	q, err := Compile("1 + 2")
+	assert.Nil(t, err)
+	assert.Equal(t, "1 + 2", q.Expr)
+	_, err = Compile("1 +")
+	assert.NotNil(t, err)
+}
+
+func TestCompileConcurrently(t *testing.T) {
+	// Run with -race: each parse must keep its tree to itself.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			expr := fmt.Sprintf("%d + %d", i, i)
+			q, err := Compile(expr)
+			if assert.Nil(t, err) {
+				it, err := q.Eval(nil, nil)
+				assert.Nil(t, err)
+				assertResults(t, it, fmt.Sprint(2*i))
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestQueryEval(t *testing.T) {
+	ctx := DefaultContext()
+	ctx.FS = fstest.MapFS{
+		"src/a.go": {Data: []byte("package a\n")},
+		"src/b.go": {Data: []byte("package b\n")},
+		"README":   {Data: []byte("hello\n")},
+	}
+	root, err := FileAt(ctx, "src")
+	assert.Nil(t, err)
+	assert.Equal(t, "/src", root.Path)
+
+	q, err := Compile("*.go")
+	assert.Nil(t, err)
+	it, err := q.Eval(ctx, root)
+	assert.Nil(t, err)
+	assertResults(t, it, "a.go", "b.go")
+
+	// The context isn't changed, so it can be used again from another root.
+	root, err = FileAt(ctx, "/")
+	assert.Nil(t, err)
+	it, err = q.Eval(ctx, root)
+	assert.Nil(t, err)
+	assertResults(t, it)
+	wd, _ := os.Getwd()
+	assert.Equal(t, wd, ctx.ContextItem.(*FileItem).Path)
+
+	// Items other than files can be the root, and variables are passed along.
+	ctx.Variables["suffix"] = []Item{newStringItem("!")}
+	q, err = Compile("concat(., $suffix)")
+	assert.Nil(t, err)
+	it, err = q.Eval(ctx, newStringItem("hi"))
+	assert.Nil(t, err)
+	assertResults(t, it, "hi!")
+}
+
+func TestQueryErrors(t *testing.T) {
+	q, err := Compile("$missing")
+	assert.Nil(t, err)
+	_, err = q.Eval(nil, nil)
+	assert.NotNil(t, err)
+
+	// Errors may only come up while iterating.
+	q, err = Compile("(1, 2, 'a')[. + 1 > 0]")
+	assert.Nil(t, err)
+	it, err := q.Eval(nil, nil)
+	assert.Nil(t, err)
+	assert.True(t, it.Next())
+	assert.True(t, it.Next())
+	assert.False(t, it.Next())
+	assert.NotNil(t, it.Err())
+	assert.False(t, it.Next())
+}
+
+func TestBindVariable(t *testing.T) {
+	ctx := DefaultContext()
+	ctx.FS = fstest.MapFS{
+		"src/a.go": {Data: []byte("package a\n")},
+		"src/b.go": {Data: []byte("package b\n")},
+	}
+	assert.Nil(t, BindVariable(ctx, "pattern", "", "a.go"))
+	assert.Nil(t, BindVariable(ctx, "limit", TYPE_INTEGER, "1"))
+	assert.Nil(t, BindVariable(ctx, "src-dir", TYPE_FILE, "src"))
+	q, err := Compile("$src-dir/*[name() = $pattern or position() > $limit]")
+	assert.Nil(t, err)
+	it, err := q.Eval(ctx, nil)
+	assert.Nil(t, err)
+	assertResults(t, it, "a.go", "b.go")
+
+	// Quotes in values are just part of the string.
+	assert.Nil(t, BindVariable(ctx, "quoted", TYPE_STRING, `it's "quoted"`))
+	q, err = Compile("string-length($quoted)")
+	assert.Nil(t, err)
+	it, err = q.Eval(ctx, nil)
+	assert.Nil(t, err)
+	assertResults(t, it, "13")
+
+	assert.NotNil(t, BindVariable(ctx, "limit", TYPE_INTEGER, "ten"))
+	assert.NotNil(t, BindVariable(ctx, "src-dir", TYPE_FILE, "missing"))
+	assert.NotNil(t, BindVariable(ctx, "when", TYPE_TIME, "now"))
+	assert.NotNil(t, BindVariable(ctx, "1st", "", "x"))
+	assert.NotNil(t, BindVariable(ctx, "a b", "", "x"))
+	assert.Equal(t, []Item{newIntegerItem(1)}, ctx.Variables["limit"])
+
+	// Variables may be named after keywords, like "--var file=a.go".
+	assert.Nil(t, BindVariable(ctx, "file", "", "a.go"))
+	assert.Nil(t, BindVariable(ctx, "in", TYPE_FILE, "src"))
+	assert.Nil(t, BindVariable(ctx, "div", TYPE_INTEGER, "2"))
+	q, err = Compile("$in/*[name() = $file], $div div $div")
+	assert.Nil(t, err)
+	it, err = q.Eval(ctx, nil)
+	assert.Nil(t, err)
+	assertResults(t, it, "a.go", "1")
+}
+
+func TestEvalPartialContext(t *testing.T) {
+	// A context with nothing set gets the defaults, and its own variables.
+	q, err := Compile("let $x := 1 return for $y in (1, 2) return $x + $y")
+	assert.Nil(t, err)
+	ctx := &Context{}
+	it, err := q.Eval(ctx, nil)
+	assert.Nil(t, err)
+	assertResults(t, it, "2", "3")
+	assert.Nil(t, ctx.Variables)
+
+	ctx = &Context{FS: fstest.MapFS{"a/b.txt": {Data: []byte("b\n")}}}
+	q, err = Compile("a/*[file-contains(., 'b')]/path()")
+	assert.Nil(t, err)
+	it, err = q.Eval(ctx, nil)
+	assert.Nil(t, err)
+	assertResults(t, it, "/a/b.txt")
+}
+
+func TestEvalConcurrently(t *testing.T) {
+	// Run with -race: queries on the same context bind variables separately.
+	ctx := DefaultContext()
+	ctx.Variables["n"] = []Item{newIntegerItem(10)}
+	q, err := Compile("count(for $i in 1 to $n return let $j := $i * 2 return $j[. > 10])")
+	assert.Nil(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			it, err := q.Eval(ctx, nil)
+			if assert.Nil(t, err) {
+				assertResults(t, it, "5")
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, ctx.Variables, 1)
+}
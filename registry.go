@@ -0,0 +1,220 @@
+/*
+registry.go contains the API for adding functions and axes to DPath from Go
+code, along with the signatures which describe functions and check their calls.
+*/
+
+package dpath
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+A Param describes one parameter of a builtin function: its name (for
+documentation), the type of its items, and how many items it takes.
+
+Type is "item" for any item, "numeric" for integers and doubles, or the name of
+an item type, like "string" or "file". Attributes, lines and document nodes have
+the type of their values too, so an attribute like @size can be passed as a
+"numeric". Files can be passed as a "string", which is their name.
+
+Occurrence is "" for exactly one item, "?" for zero or one, "*" for any number
+and "+" for one or more, as in XPath.
+*/
+type Param struct {
+	Name       string
+	Type       string
+	Occurrence string
+}
+
+/*
+The types which parameters may have.
+*/
+var paramTypes = map[string]bool{
+	"item": true, "numeric": true, TYPE_INTEGER: true, TYPE_DOUBLE: true,
+	TYPE_BOOLEAN: true, TYPE_STRING: true, TYPE_FILE: true, TYPE_ATTR: true,
+	TYPE_TIME: true, TYPE_DUR: true, TYPE_LINE: true, TYPE_NODE: true,
+}
+
+/*
+Return true if an item may be passed to a parameter of a type.
+*/
+func matchesType(item Item, typeName string) bool {
+	value := atomize(item)
+	switch typeName {
+	case "item":
+		return true
+	case "numeric":
+		return value.TypeName() == TYPE_INTEGER || value.TypeName() == TYPE_DOUBLE
+	case TYPE_STRING:
+		return value.TypeName() == TYPE_STRING || value.TypeName() == TYPE_FILE
+	}
+	return item.TypeName() == typeName || value.TypeName() == typeName
+}
+
+/*
+Return the parameter which describes an argument of a builtin, or nil if there
+isn't one.
+*/
+func (b *Builtin) param(i int) *Param {
+	if i < len(b.Params) {
+		return &b.Params[i]
+	} else if b.MaxArgs < 0 && len(b.Params) > 0 {
+		return &b.Params[len(b.Params)-1]
+	}
+	return nil
+}
+
+/*
+Return an error if a builtin can't be called with a number of arguments.
+*/
+func (b *Builtin) checkArity(n int) error {
+	if n >= b.MinArgs && (b.MaxArgs < 0 || n <= b.MaxArgs) {
+		return nil
+	}
+	var expected string
+	switch {
+	case b.MaxArgs < 0:
+		expected = fmt.Sprintf("at least %d", b.MinArgs)
+	case b.MinArgs == b.MaxArgs:
+		expected = fmt.Sprintf("%d", b.MinArgs)
+	default:
+		expected = fmt.Sprintf("%d to %d", b.MinArgs, b.MaxArgs)
+	}
+	return fmt.Errorf("in call to %s, expected %s args, got %d", b.Name, expected, n)
+}
+
+/*
+Signature returns a description of how to call a builtin, in the style of the
+XPath function reference, like:
+
+	substring($string as string?, $start as numeric[, $length as numeric]) as string
+*/
+func (b *Builtin) Signature() string {
+	var sig strings.Builder
+	sig.WriteString(b.Name + "(")
+	for i, param := range b.Params {
+		arg := "$" + param.Name + " as " + param.Type + param.Occurrence
+		if i > 0 {
+			arg = ", " + arg
+		}
+		if i >= b.MinArgs {
+			arg = "[" + arg + "]"
+		}
+		sig.WriteString(arg)
+	}
+	if b.MaxArgs < 0 {
+		sig.WriteString(", ...")
+	}
+	sig.WriteString(")")
+	if b.Returns != "" {
+		sig.WriteString(" as " + b.Returns)
+	}
+	return sig.String()
+}
+
+/*
+Return an error if a builtin's signature doesn't make sense.
+*/
+func validateBuiltin(b Builtin) error {
+	if b.Name == "" || b.Invoke == nil {
+		return errors.New("a builtin needs a name and an Invoke function")
+	}
+	if b.MinArgs < 0 || (b.MaxArgs >= 0 && b.MaxArgs < b.MinArgs) {
+		return fmt.Errorf("builtin %s has an invalid number of arguments", b.Name)
+	}
+	if b.MaxArgs >= 0 && len(b.Params) != b.MaxArgs {
+		return fmt.Errorf("builtin %s should have a Param for each argument", b.Name)
+	}
+	if b.MaxArgs < 0 && (len(b.Params) == 0 || len(b.Params) < b.MinArgs) {
+		return fmt.Errorf("builtin %s should have a Param for each argument", b.Name)
+	}
+	for _, param := range b.Params {
+		if !paramTypes[param.Type] {
+			return fmt.Errorf("builtin %s has a parameter of unknown type %q", b.Name, param.Type)
+		}
+		if !strings.Contains("?*+", param.Occurrence) || len(param.Occurrence) > 1 {
+			return fmt.Errorf("builtin %s has a parameter with unknown occurrence %q", b.Name, param.Occurrence)
+		}
+	}
+	return nil
+}
+
+/*
+RegisterBuiltin adds a function to the namespace of every context made by
+DefaultContext() (or DefaultNamespace()) from then on. Builtins which have the
+name of another one can't be registered. This isn't safe to call concurrently,
+so it's best called from an init() function.
+
+To add a function to a single context, add it to the context's Namespace.
+*/
+func RegisterBuiltin(b Builtin) error {
+	if err := validateBuiltin(b); err != nil {
+		return err
+	}
+	if _, ok := registeredBuiltins[b.Name]; ok {
+		return fmt.Errorf("builtin %s is already registered", b.Name)
+	}
+	registeredBuiltins[b.Name] = b
+	return nil
+}
+
+/*
+Builtins returns every registered builtin, sorted by name.
+*/
+func Builtins() []Builtin {
+	builtins := make([]Builtin, 0, len(registeredBuiltins))
+	for _, builtin := range registeredBuiltins {
+		builtins = append(builtins, builtin)
+	}
+	sort.Slice(builtins, func(i, j int) bool {
+		return builtins[i].Name < builtins[j].Name
+	})
+	return builtins
+}
+
+/*
+AxisInfo describes an axis which can be registered: the name used before "::"
+in a step, and what it contains, for documentation.
+*/
+type AxisInfo struct {
+	Name string
+	Doc  string
+	Axis Axis
+}
+
+/*
+RegisterAxis adds an axis to every context made by DefaultContext() (or
+DefaultAxes()) from then on. Every context shares the Axis, so it shouldn't
+keep any state of its own. Like RegisterBuiltin(), it can't replace an axis
+with the same name, and it isn't safe to call concurrently.
+
+To add an axis to a single context, add it to the context's Axes.
+*/
+func RegisterAxis(info AxisInfo) error {
+	if info.Name == "" || info.Axis == nil {
+		return errors.New("an axis needs a name and an Axis")
+	}
+	if _, ok := registeredAxes[info.Name]; ok {
+		return fmt.Errorf("axis %s is already registered", info.Name)
+	}
+	registeredAxes[info.Name] = info
+	return nil
+}
+
+/*
+Axes returns the description of every registered axis, sorted by name.
+*/
+func Axes() []AxisInfo {
+	axes := make([]AxisInfo, 0, len(registeredAxes))
+	for _, info := range registeredAxes {
+		axes = append(axes, info)
+	}
+	sort.Slice(axes, func(i, j int) bool {
+		return axes[i].Name < axes[j].Name
+	})
+	return axes
+}
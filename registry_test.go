@@ -0,0 +1,129 @@
+package dpath
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+/*
+Evaluate an expression completely, returning the first error.
+*/
+func evaluateAll(ctx *Context, expr string) ([]Item, error) {
+	tree, err := ParseString(expr)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := tree.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return seqToSlice(seq, ctx)
+}
+
+func TestBuiltinSignatures(t *testing.T) {
+	for _, builtin := range Builtins() {
+		assert.Nil(t, validateBuiltin(builtin), builtin.Name)
+		assert.NotEmpty(t, builtin.Doc, builtin.Name)
+	}
+	assert.Equal(t,
+		"substring($string as string?, $start as numeric[, $length as numeric]) as string",
+		BUILTIN_SUBSTRING.Signature())
+	assert.Equal(t, "concat($value as item, ...) as string", BUILTIN_CONCAT.Signature())
+	assert.Equal(t, "true() as boolean", BUILTIN_TRUE.Signature())
+	for _, info := range Axes() {
+		assert.NotEmpty(t, info.Doc, info.Name)
+	}
+}
+
+func TestBuiltinArguments(t *testing.T) {
+	cases := []struct {
+		expr string
+		ok   bool
+	}{
+		{"round(1.6)", true},
+		{"round('a')", false},
+		{"round(())", false},
+		{"round((1, 2))", false},
+		{"substring('abc', 2)", true},
+		{"substring((), 2)", true},
+		{"substring('abc')", false},
+		{"substring('abc', 1, 2, 3)", false},
+		{"substring('abc', '1')", false},
+		{"starts-with(., 'M')", true},
+		{"concat()", false},
+		{"concat(1, 'a', 2.5)", true},
+		{"format-size(1024)", true},
+		{"format-size('big')", false},
+		{"line-number(1)", false},
+		{"count((1, 'a', .))", true},
+	}
+	for _, c := range cases {
+		ctx := MockDefaultContext()
+		_, err := evaluateAll(ctx, c.expr)
+		if c.ok {
+			assert.Nil(t, err, c.expr)
+		} else {
+			assert.NotNil(t, err, c.expr)
+		}
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	teams := Builtin{
+		Name:    "owner-team",
+		Params:  []Param{{"file", TYPE_FILE, ""}},
+		MinArgs: 0,
+		MaxArgs: 1,
+		Returns: TYPE_STRING,
+		Doc:     "The team which owns a file.",
+		Invoke: func(ctx *Context, args ...Sequence) (Sequence, error) {
+			item := ctx.ContextItem
+			if len(args) == 1 {
+				var err error
+				if item, err = getSingleItem(ctx, args[0]); err != nil {
+					return nil, err
+				}
+			}
+			return newSingletonSequence(newStringItem("team-" + item.ToString())), nil
+		},
+	}
+	assert.Nil(t, RegisterBuiltin(teams))
+	defer delete(registeredBuiltins, "owner-team")
+	assert.NotNil(t, RegisterBuiltin(teams))
+	assert.NotNil(t, RegisterBuiltin(Builtin{Name: "no-invoke"}))
+	broken := teams
+	broken.Name = "broken"
+	broken.Params = []Param{{"file", "files", ""}}
+	assert.NotNil(t, RegisterBuiltin(broken))
+	broken.Params = nil
+	assert.NotNil(t, RegisterBuiltin(broken))
+
+	ctx := MockDefaultContext()
+	items, err := evaluateAll(ctx, "owner-team()")
+	assert.Nil(t, err)
+	assert.Equal(t, []Item{newStringItem("team-MockedDir")}, items)
+	_, err = evaluateAll(ctx, "owner-team('a')")
+	assert.NotNil(t, err)
+
+	// Contexts made before the builtin was registered don't have it.
+	ctx.Namespace = map[string]Builtin{}
+	_, err = evaluateAll(ctx, "owner-team()")
+	assert.NotNil(t, err)
+}
+
+func TestRegisterAxis(t *testing.T) {
+	owners := &mapAxis{Paths: map[string][]string{
+		"/MockedDir": {"/teams/core", "/teams/docs"},
+	}}
+	assert.Nil(t, RegisterAxis(AxisInfo{Name: "codeowners", Axis: owners, Doc: "Owners."}))
+	defer delete(registeredAxes, "codeowners")
+	assert.NotNil(t, RegisterAxis(AxisInfo{Name: "codeowners", Axis: owners}))
+	assert.NotNil(t, RegisterAxis(AxisInfo{Name: "nothing"}))
+	assert.Equal(t, owners, DefaultAxes()["codeowners"])
+
+	ctx := MockDefaultContext()
+	ctx.Axes = DefaultAxes()
+	items, err := evaluateAll(ctx, "codeowners::*/name()")
+	assert.Nil(t, err)
+	assert.Equal(t, []Item{newStringItem("core"), newStringItem("docs")}, items)
+}
@@ -2,10 +2,20 @@
 sequence.go contains the Sequence interface and implementations thereof.
 */
 
-package main
+package dpath
 
 import (
-	log "github.com/Sirupsen/logrus"
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
 )
 
 /*
@@ -131,55 +141,127 @@ func (s *RangeSequence) Next(ctx *Context) (bool, error) {
 
 /*
 ExpressionFilter is a "filtering" sequence, meaning that it takes a source
-sequence and filters items by a condition. In this case, the condition is a list
-of expressions from predicates. These are evaluated in order, and an item is
-yielded only if it satisfies every expression. The expression is converted to
-boolean by the built-in boolean() function -- see BuiltinBooleanInvoke().
+sequence and filters items by a condition. In this case, the condition is the
+expression from a predicate, which is evaluated with each item as the context
+item. When the expression evaluates to a single number, the item is yielded only
+if its position within the source equals that number. Otherwise, the expression
+is converted to boolean by the built-in boolean() function -- see
+BuiltinBooleanInvoke(). A list of predicates is implemented by chaining several
+ExpressionFilters together.
+
+The context position and size are available within the predicate through the
+position() and last() functions. The size is only computed if it is asked for,
+since that requires reading the rest of the source sequence into Buffer.
 */
 type ExpressionFilter struct {
-	Source  Sequence
-	Current Item
-	Filters []ParseTree
+	Source   Sequence
+	Current  Item
+	Filter   ParseTree
+	Position int64
+	Buffer   []Item
+	Drained  bool
 }
 
 /*
 Return a new instance of ExpressionFilter.
 */
-func newExpressionFilter(src Sequence, f []ParseTree) *ExpressionFilter {
-	return &ExpressionFilter{Source: src, Current: nil, Filters: f}
+func newExpressionFilter(src Sequence, f ParseTree) *ExpressionFilter {
+	return &ExpressionFilter{Source: src, Current: nil, Filter: f}
 }
 
 func (f *ExpressionFilter) Value() Item {
 	return f.Current
 }
 
-func (f *ExpressionFilter) Next(ctx *Context) (bool, error) {
-	var e error = nil
-	// Outer loop iterates over items from the source sequence. It terminates when
-	// an item that satisfies all conditions, or when the source is exhausted.
-OUTER:
-	for r, e := f.Source.Next(ctx); r && e == nil; r, e = f.Source.Next(ctx) {
-		// The context item needs to be set to the current item when evaluating the
-		// conditions.
+/*
+Advance to the next item from the source, which may have been read ahead into
+the buffer.
+*/
+func (f *ExpressionFilter) advance(ctx *Context) (bool, error) {
+	if len(f.Buffer) > 0 {
+		f.Current = f.Buffer[0]
+		f.Buffer = f.Buffer[1:]
+		return true, nil
+	} else if f.Drained {
+		return false, nil
+	}
+	hasNext, err := f.Source.Next(ctx)
+	if hasNext && err == nil {
 		f.Current = f.Source.Value()
-		oldCtxItem := ctx.ContextItem
-		ctx.ContextItem = f.Current
-		// Inner loop goes over each expression.
-		for _, filter := range f.Filters {
-			res, err := execBuiltin(ctx, "boolean", filter)
-			if err != nil {
-				ctx.ContextItem = oldCtxItem
-				return false, err
-			}
-			if !getBool(panicUnlessOne(ctx, res)) {
-				ctx.ContextItem = oldCtxItem
-				continue OUTER
-			}
+	}
+	return hasNext, err
+}
+
+/*
+Return the size of the source sequence, for the last() function.
+*/
+func (f *ExpressionFilter) size(ctx *Context) (int64, error) {
+	if !f.Drained {
+		rest, err := seqToSlice(f.Source, ctx)
+		if err != nil {
+			return 0, err
+		}
+		f.Buffer = append(f.Buffer, rest...)
+		f.Drained = true
+	}
+	return f.Position + int64(len(f.Buffer)), nil
+}
+
+/*
+Evaluate the filter expression for the current item, returning whether the
+item should be yielded.
+*/
+func (f *ExpressionFilter) test(ctx *Context) (bool, error) {
+	oldCtxItem, oldPosition, oldSize := ctx.ContextItem, ctx.ContextPosition, ctx.ContextSize
+	ctx.ContextItem, ctx.ContextPosition, ctx.ContextSize = f.Current, f.Position, f.size
+	res, err := f.Filter.Evaluate(ctx)
+	// The boolean() function needs at most two items to decide on the value,
+	// so that is all we need to read (while the context is still set).
+	var items []Item
+	for i := 0; i < 2 && err == nil; i++ {
+		var hasNext bool
+		if hasNext, err = res.Next(ctx); hasNext && err == nil {
+			items = append(items, res.Value())
+		} else {
+			break
+		}
+	}
+	ctx.ContextItem, ctx.ContextPosition, ctx.ContextSize = oldCtxItem, oldPosition, oldSize
+	if err != nil {
+		return false, err
+	}
+
+	if len(items) == 1 && (items[0].TypeName() == TYPE_INTEGER ||
+		items[0].TypeName() == TYPE_DOUBLE) {
+		return getNumericAsFloat(items[0]) == float64(f.Position), nil
+	}
+	boolSeq, err := BuiltinBooleanInvoke(ctx, newWrapperSequence(items))
+	if err != nil {
+		return false, err
+	}
+	return getBool(panicUnlessOne(ctx, boolSeq)), nil
+}
+
+func (f *ExpressionFilter) Next(ctx *Context) (bool, error) {
+	// A literal number can only match a single position, so there is no need to
+	// look any further once we're past it.
+	if lit, ok := f.Filter.(*LiteralTree); ok && lit.Type == TYPE_INTEGER &&
+		f.Position >= lit.IntegerValue {
+		return false, nil
+	}
+	for {
+		hasNext, err := f.advance(ctx)
+		if !hasNext || err != nil {
+			return hasNext, err
+		}
+		f.Position++
+		keep, err := f.test(ctx)
+		if err != nil {
+			return false, err
+		} else if keep {
+			return true, nil
 		}
-		ctx.ContextItem = oldCtxItem
-		return true, nil
 	}
-	return false, e
 }
 
 /*
@@ -206,8 +288,9 @@ func (f *ConditionFilter) Value() Item {
 }
 
 func (f *ConditionFilter) Next(ctx *Context) (bool, error) {
+	var r bool
 	var e error = nil
-	for r, e := f.Source.Next(ctx); r && e == nil; r, e = f.Source.Next(ctx) {
+	for r, e = f.Source.Next(ctx); r && e == nil; r, e = f.Source.Next(ctx) {
 		f.Current = f.Source.Value()
 		if f.Filter(f.Current) {
 			return true, nil
@@ -216,14 +299,222 @@ func (f *ConditionFilter) Next(ctx *Context) (bool, error) {
 	return false, e
 }
 
+/*
+UniqueFileSequence yields the files from a source sequence, skipping any file it
+has already yielded. Files are identified by their path. This is the basis for
+the union, intersect and except operators, which only operate on files, so it
+is an error for the source sequence to contain anything else.
+*/
+type UniqueFileSequence struct {
+	Source Sequence
+	Seen   map[string]bool
+}
+
+/*
+Return a new UniqueFileSequence over src.
+*/
+func newUniqueFileSequence(src Sequence) *UniqueFileSequence {
+	return &UniqueFileSequence{Source: src, Seen: map[string]bool{}}
+}
+
+func (s *UniqueFileSequence) Next(ctx *Context) (bool, error) {
+	var r bool
+	var e error = nil
+	for r, e = s.Source.Next(ctx); r && e == nil; r, e = s.Source.Next(ctx) {
+		file, ok := s.Source.Value().(*FileItem)
+		if !ok {
+			return false, errors.New("set operators expect sequences of files")
+		}
+		if !s.Seen[file.Path] {
+			s.Seen[file.Path] = true
+			return true, nil
+		}
+	}
+	return false, e
+}
+
+func (s *UniqueFileSequence) Value() Item {
+	return s.Source.Value()
+}
+
+/*
+Read an entire sequence of files into a set of their paths.
+*/
+func fileSet(ctx *Context, seq Sequence) (map[string]bool, error) {
+	var hasNext bool
+	var err error
+	set := map[string]bool{}
+	for hasNext, err = seq.Next(ctx); hasNext && err == nil; hasNext, err = seq.Next(ctx) {
+		file, ok := seq.Value().(*FileItem)
+		if !ok {
+			return nil, errors.New("set operators expect sequences of files")
+		}
+		set[file.Path] = true
+	}
+	return set, err
+}
+
+/*
+Read an entire sequence of files and return them in document order, without
+duplicates.
+*/
+func sortFiles(ctx *Context, seq Sequence) (Sequence, error) {
+	files := []*FileItem{}
+	for {
+		file, err := nextFile(ctx, seq)
+		if err != nil {
+			return nil, err
+		} else if file == nil {
+			break
+		}
+		files = append(files, file)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return documentOrder(files[i], files[j]) < 0
+	})
+	items := make([]Item, 0, len(files))
+	for i, file := range files {
+		if i == 0 || documentOrder(files[i-1], file) != 0 {
+			items = append(items, file)
+		}
+	}
+	return newWrapperSequence(items), nil
+}
+
+/*
+FileMergeSequence merges two sequences of files which are each in document
+order, yielding every file from both in document order.
+*/
+type FileMergeSequence struct {
+	Left, Right         Sequence
+	LeftFile, RightFile *FileItem
+	Started             bool
+	Current             *FileItem
+}
+
+/*
+Return a new FileMergeSequence of left and right.
+*/
+func newFileMergeSequence(left, right Sequence) *FileMergeSequence {
+	return &FileMergeSequence{Left: left, Right: right}
+}
+
+/*
+Return the next file of a sequence, or nil once it's finished.
+*/
+func nextFile(ctx *Context, seq Sequence) (*FileItem, error) {
+	hasNext, err := seq.Next(ctx)
+	if err != nil || !hasNext {
+		return nil, err
+	}
+	file, ok := seq.Value().(*FileItem)
+	if !ok {
+		return nil, errors.New("set operators expect sequences of files")
+	}
+	return file, nil
+}
+
+func (s *FileMergeSequence) Next(ctx *Context) (bool, error) {
+	var err error
+	if !s.Started {
+		s.Started = true
+		if s.LeftFile, err = nextFile(ctx, s.Left); err != nil {
+			return false, err
+		}
+		if s.RightFile, err = nextFile(ctx, s.Right); err != nil {
+			return false, err
+		}
+	}
+	if s.LeftFile == nil && s.RightFile == nil {
+		return false, nil
+	}
+	if s.RightFile == nil || (s.LeftFile != nil && documentOrder(s.LeftFile, s.RightFile) <= 0) {
+		s.Current = s.LeftFile
+		s.LeftFile, err = nextFile(ctx, s.Left)
+	} else {
+		s.Current = s.RightFile
+		s.RightFile, err = nextFile(ctx, s.Right)
+	}
+	return err == nil, err
+}
+
+func (s *FileMergeSequence) Value() Item {
+	return s.Current
+}
+
+/*
+Return the union of two sequences of files in document order, without
+duplicates. The union is lazy, merging the files of left and right as they come,
+so each should already be in document order (as the results of paths are, or see
+sortFiles()).
+*/
+func FileUnion(ctx *Context, left, right Sequence) (Sequence, error) {
+	return newUniqueFileSequence(newFileMergeSequence(left, right)), nil
+}
+
+/*
+Return the files from left which are also in right, without duplicates. They
+come in the order of left, which should be document order, like for FileUnion().
+The right sequence is read in full before returning.
+*/
+func FileIntersect(ctx *Context, left, right Sequence) (Sequence, error) {
+	set, err := fileSet(ctx, right)
+	if err != nil {
+		return nil, err
+	}
+	return newConditionFilter(newUniqueFileSequence(left), func(it Item) bool {
+		return set[getFile(it).Path]
+	}), nil
+}
+
+/*
+Return the files from left which are not in right, without duplicates. They
+come in the order of left, which should be document order, like for FileUnion().
+The right sequence is read in full before returning.
+*/
+func FileExcept(ctx *Context, left, right Sequence) (Sequence, error) {
+	set, err := fileSet(ctx, right)
+	if err != nil {
+		return nil, err
+	}
+	return newConditionFilter(newUniqueFileSequence(left), func(it Item) bool {
+		return !set[getFile(it).Path]
+	}), nil
+}
+
+/*
+fileHeap is a min-heap of files in document order, for use with container/heap.
+*/
+type fileHeap []*FileItem
+
+func (h fileHeap) Len() int            { return len(h) }
+func (h fileHeap) Less(i, j int) bool  { return documentOrder(h[i], h[j]) < 0 }
+func (h fileHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *fileHeap) Push(x interface{}) { *h = append(*h, x.(*FileItem)) }
+func (h *fileHeap) Pop() interface{} {
+	old := *h
+	f := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return f
+}
+
 /*
 PathSequence is used to implement each step of a path expression. It takes two
 things. First, a sequence of input, generally from the previous step along the
 path expression. Second, an expression to be evaluated for each Item from the
 input sequence, thus producing the output of this Sequence.
 
-The total output of a PathSequence is the concatenated output of the sequences
-produced by expression when evaluated on each Item from the input Sequence.
+The output of a PathSequence is the output of the sequences produced by the
+expression when evaluated on each Item from the input Sequence. As in XPath,
+files are returned in document order, and the same file is never returned
+twice. Other items (such as the results of name()) are returned as they come.
+
+To do this without reading every file into memory, files are held in a heap
+until nothing that comes later could precede them. When Ordered is true, the
+input is in document order and the step expression only goes forward from each
+context item (e.g. the child or descendant axis), so every file before the
+current context item may be released. Otherwise, everything is held until the
+input is exhausted.
 
 Larger path expressions are built by simply chaining these PathSequences
 together.
@@ -232,19 +523,51 @@ type PathSequence struct {
 	CtxSource  Sequence
 	Expression ParseTree
 	Source     Sequence
+	Ordered    bool
+	Bound      *FileItem
+	Pending    fileHeap
+	Last       *FileItem
+	Current    Item
+	Done       bool
+}
+
+/*
+Return a new PathSequence given the source sequence and step expression. See
+PathSequence for the meaning of ordered.
+*/
+func newPathSequence(src Sequence, expr ParseTree, ordered bool) *PathSequence {
+	return &PathSequence{CtxSource: src, Expression: expr, Source: nil, Ordered: ordered}
 }
 
 /*
-Return a new PathSequence given the source sequence and step expression.
+Return true if the file at the top of the heap may be returned.
 */
-func newPathSequence(src Sequence, expr ParseTree) *PathSequence {
-	return &PathSequence{CtxSource: src, Expression: expr, Source: nil}
+func (s *PathSequence) releasable() bool {
+	if len(s.Pending) == 0 {
+		return false
+	}
+	return s.Done || (s.Bound != nil && documentOrder(s.Pending[0], s.Bound) < 0)
 }
 
 func (s *PathSequence) Next(ctx *Context) (b bool, e error) {
 	var err error = nil
 	var hasNext bool
 	for {
+		// Return pending files first, skipping duplicates. Since files come
+		// out of the heap in order, any duplicates come out one after another.
+		if s.releasable() {
+			file := heap.Pop(&s.Pending).(*FileItem)
+			if s.Last != nil && documentOrder(s.Last, file) == 0 {
+				continue
+			}
+			s.Last = file
+			s.Current = file
+			return true, nil
+		} else if s.Done {
+			s.Current = nil
+			return false, nil
+		}
+
 		if s.Source != nil {
 			// Replace the context item with the one from the previous point
 			// in the path. Then attempt to advance the source sequence.
@@ -255,6 +578,11 @@ func (s *PathSequence) Next(ctx *Context) (b bool, e error) {
 			if err != nil {
 				return false, err
 			} else if hasNext {
+				if file, ok := s.Source.Value().(*FileItem); ok {
+					heap.Push(&s.Pending, file)
+					continue
+				}
+				s.Current = s.Source.Value()
 				return true, nil
 			}
 			// Continue on if no error and the source sequence is empty.
@@ -262,9 +590,13 @@ func (s *PathSequence) Next(ctx *Context) (b bool, e error) {
 
 		// Get the next input from our input sequence.
 		hasNext, err = s.CtxSource.Next(ctx)
-		if !hasNext || err != nil {
-			// Return if we're out of context items, or if we've got an error.
-			return hasNext, err
+		if err != nil {
+			return false, err
+		} else if !hasNext {
+			// Out of context items, so release whatever is left.
+			s.Done = true
+			s.Source = nil
+			continue
 		}
 
 		// Use the current item from our input sequence as the context for
@@ -276,12 +608,75 @@ func (s *PathSequence) Next(ctx *Context) (b bool, e error) {
 		if err != nil {
 			return false, err
 		}
+		if file, ok := s.CtxSource.Value().(*FileItem); ok && s.Ordered {
+			s.Bound = file
+		}
 		// Fall through back to the top of the loop to try to get items from the
 		// source again.
 	}
 }
 
 func (s *PathSequence) Value() Item {
+	return s.Current
+}
+
+/*
+ForSequence implements the for expression. It works much like a PathSequence,
+except that each item from the input sequence is bound to a variable rather
+than being made the context item. The output is the concatenated output of the
+return expression, evaluated once for each input item.
+*/
+type ForSequence struct {
+	CtxSource  Sequence
+	Variable   string
+	Expression ParseTree
+	Source     Sequence
+}
+
+/*
+Return a new ForSequence binding variable to each item of src in turn, and
+evaluating expr for each.
+*/
+func newForSequence(src Sequence, variable string, expr ParseTree) *ForSequence {
+	return &ForSequence{
+		CtxSource: src, Variable: variable, Expression: expr, Source: nil,
+	}
+}
+
+func (s *ForSequence) Next(ctx *Context) (bool, error) {
+	var err error = nil
+	var hasNext bool
+	for {
+		if s.Source != nil {
+			// The return expression's sequence may still refer to the variable
+			// while it is advanced, so re-bind it first.
+			old := bindVariable(ctx, s.Variable, []Item{s.CtxSource.Value()})
+			hasNext, err = s.Source.Next(ctx)
+			restoreVariable(ctx, s.Variable, old)
+			if err != nil {
+				return false, err
+			} else if hasNext {
+				return true, nil
+			}
+		}
+
+		// Get the next item to bind. This happens outside of the binding, since
+		// the input sequence is not within the scope of the variable.
+		hasNext, err = s.CtxSource.Next(ctx)
+		if !hasNext || err != nil {
+			return hasNext, err
+		}
+
+		old := bindVariable(ctx, s.Variable, []Item{s.CtxSource.Value()})
+		s.Source, err = s.Expression.Evaluate(ctx)
+		restoreVariable(ctx, s.Variable, old)
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+func (s *ForSequence) Value() Item {
 	if s.Source != nil {
 		return s.Source.Value()
 	} else {
@@ -333,79 +728,411 @@ func (s *ConcatenateSequence) Value() Item {
 
 /*
 DescendentSequence is a rather tricky sequence whose job it is to return every
-descendant of a file. It does this in a depth-first manner by directory.
+descendant of a file. It does this in document order, which is a depth-first
+pre-order traversal: each directory is yielded just before its contents. The
+stack holds the child sequences of each directory we are currently inside of.
+
+When the context says to follow symbolic links, a link to a directory is visited
+like any other directory, unless the directory is already on the stack. That
+would be a cycle, which would go on forever. Directories are identified by their
+device and inode numbers, or in file systems without them, by their paths with
+every link resolved (see evalSymlinks()). Dirs holds this key for each directory
+on the stack. It is only filled in when following links, and it is "" when the
+key isn't known.
 */
 type DescendantSequence struct {
-	Source  Sequence
-	ToVisit []*FileItem
+	Start   *FileItem
+	Started bool
+	Stack   []Sequence
+	Dirs    []string
+	Current *FileItem
 }
 
 /*
 Return a sequence of all descendant files of start.
 */
 func newDescendantSequence(start *FileItem) *DescendantSequence {
-	return &DescendantSequence{Source: nil, ToVisit: []*FileItem{start}}
+	return &DescendantSequence{Start: start, Started: false, Stack: nil, Dirs: nil, Current: nil}
+}
+
+/*
+Return a sequence of the files in each of the given sequences along with all of
+their descendants, starting with the last sequence. This is used for axes which
+cover several directories' worth of descendants, like following.
+*/
+func newDescendantSequenceFrom(stack []Sequence) *DescendantSequence {
+	dirs := make([]string, len(stack))
+	return &DescendantSequence{Start: nil, Started: true, Stack: stack, Dirs: dirs, Current: nil}
+}
+
+/*
+Push the children of a directory onto the stack, so that they are visited next.
+*/
+func (s *DescendantSequence) descend(ctx *Context, dir *FileItem, key string) error {
+	oldCtx := ctx.ContextItem
+	ctx.ContextItem = dir
+	log.WithFields(log.Fields{
+		"axis":  "DescendantAxis",
+		"depth": len(s.Stack),
+		"item":  dir,
+	}).Debug("Starting on new source for children.")
+	children, err := ctx.Axes["child"].Iterate(ctx)
+	ctx.ContextItem = oldCtx
+	if err != nil {
+		return err
+	}
+	s.Stack = append(s.Stack, children)
+	s.Dirs = append(s.Dirs, key)
+	return nil
+}
+
+/*
+Return the key of a directory that is being visited, given its dirInfo(), when
+following links. If it has no device and inode numbers and it isn't a link, its
+resolved path is that of the directory it is in (the top of the stack) followed
+by its name, which saves resolving it again.
+*/
+func (s *DescendantSequence) identify(ctx *Context, file *FileItem, info os.FileInfo) string {
+	if !ctx.FollowLinks || info == nil {
+		return ""
+	}
+	if id, ok := fileIdentity(info); ok {
+		return id
+	}
+	if n := len(s.Dirs); n > 0 && strings.HasPrefix(s.Dirs[n-1], "/") && !isSymlink(file) {
+		return path.Join(s.Dirs[n-1], file.Info.Name())
+	}
+	resolved, err := evalSymlinks(ctx, file.Path)
+	if err != nil {
+		return ""
+	}
+	return resolved
+}
+
+/*
+Return the key of a directory (see isDir()) or archive (see isArchive()) and true
+if it should be visited. Links to directories on the stack are not, since they
+would form a cycle.
+*/
+func (s *DescendantSequence) shouldDescend(ctx *Context, file *FileItem) (string, bool) {
+	if isArchive(ctx, file) {
+		return "", true
+	}
+	info := dirInfo(ctx, file)
+	if info == nil {
+		return "", false
+	}
+	key := s.identify(ctx, file, info)
+	if !isSymlink(file) || key == "" {
+		return key, true
+	}
+	for _, dir := range s.Dirs {
+		if dir == key {
+			log.WithFields(log.Fields{
+				"axis": "DescendantAxis",
+				"item": file,
+			}).Warn("Not following symbolic link, since it forms a cycle.")
+			return "", false
+		}
+	}
+	return key, true
 }
 
 func (s *DescendantSequence) Next(ctx *Context) (bool, error) {
 	var err error = nil
 	var hasNext bool
-	for {
-		if s.Source != nil {
-			// Try to yield from the source sequence, which is the list of files in
-			// the current directory.
-			hasNext, err = s.Source.Next(ctx)
-			if err != nil {
-				return false, err
-			} else if hasNext {
-				// If there is a next item, get it and add it to the visit
-				// stack when it's a directory.
-				it := s.Source.Value().(*FileItem)
-				if it.Info.IsDir() {
-					log.WithFields(log.Fields{
-						"axis": "DescendantAxis",
-						"size": len(s.ToVisit),
-						"item": it,
-					}).Debug("Adding item to visit stack.")
-					s.ToVisit = append(s.ToVisit, it)
-				}
-				return true, nil
-			}
-			// Continue on if no error and the source sequence is empty.
-		}
 
-		// If the visit stack is empty, we're done
-		if len(s.ToVisit) <= 0 {
-			log.Debug("Iteration ending (visit stack empty).")
-			return false, nil
+	// Before moving on, visit the contents of the last item we yielded (or the
+	// starting directory, if this is the first call).
+	if !s.Started {
+		s.Started = true
+		err = s.descend(ctx, s.Start, s.identify(ctx, s.Start, dirInfo(ctx, s.Start)))
+	} else if s.Current != nil {
+		if key, ok := s.shouldDescend(ctx, s.Current); ok {
+			err = s.descend(ctx, s.Current, key)
 		}
+	}
+	s.Current = nil
+	if err != nil {
+		return false, err
+	}
 
-		// Grab the next directory from our depth-first stack of directories.
-		oldCtx := ctx.ContextItem
-		ctx.ContextItem = s.ToVisit[len(s.ToVisit)-1]
-		s.ToVisit = s.ToVisit[:len(s.ToVisit)-1]
-		log.WithFields(log.Fields{
-			"axis": "DescendantAxis",
-			"size": len(s.ToVisit),
-			"item": ctx.ContextItem,
-		}).Debug("Starting on new source for children.")
-		s.Source, err = ctx.Axes["child"].Iterate(ctx)
-		ctx.ContextItem = oldCtx
-
+	for len(s.Stack) > 0 {
+		top := s.Stack[len(s.Stack)-1]
+		hasNext, err = top.Next(ctx)
 		if err != nil {
 			return false, err
+		} else if hasNext {
+			s.Current = top.Value().(*FileItem)
+			return true, nil
 		}
-
-		// Fall through back to the top of the loop to try to get stuff from
-		// the source again.
+		// This directory is finished, so go back up to its parent.
+		s.Stack = s.Stack[:len(s.Stack)-1]
+		s.Dirs = s.Dirs[:len(s.Dirs)-1]
 	}
+	log.Debug("Iteration ending (visit stack empty).")
+	return false, nil
 }
 
 func (s *DescendantSequence) Value() Item {
-	if s.Source != nil {
-		// Make sure to add directories to the visit queue as we see them.
-		return s.Source.Value().(*FileItem)
+	if s.Current != nil {
+		return s.Current
 	} else {
 		return nil
 	}
 }
+
+/*
+BindingSequence wraps a sequence which was produced by an expression within the
+scope of a variable binding (e.g. the return expression of a let). Since
+sequences are lazy, the wrapped sequence may still need to refer to the
+variable as it is advanced, so the binding is put back in place around each
+call to Next().
+*/
+type BindingSequence struct {
+	Source   Sequence
+	Variable string
+	Binding  []Item
+}
+
+/*
+Return a new BindingSequence which yields the items of src with variable bound
+to value.
+*/
+func newBindingSequence(src Sequence, variable string, value []Item) *BindingSequence {
+	return &BindingSequence{Source: src, Variable: variable, Binding: value}
+}
+
+func (s *BindingSequence) Next(ctx *Context) (bool, error) {
+	old := bindVariable(ctx, s.Variable, s.Binding)
+	hasNext, err := s.Source.Next(ctx)
+	restoreVariable(ctx, s.Variable, old)
+	return hasNext, err
+}
+
+func (s *BindingSequence) Value() Item {
+	return s.Source.Value()
+}
+
+/*
+ScopeSequence is like BindingSequence, but it replaces all of the variables and
+functions in scope while it is advanced, rather than binding a single variable.
+It is used for the bodies of declared functions (see FunctionDecl), which can't
+see the variables of their callers. Since a body may call functions as it is
+advanced, the call depth is put back in place too.
+*/
+type ScopeSequence struct {
+	Source    Sequence
+	Variables map[string][]Item
+	Namespace map[string]Builtin
+	Depth     int
+}
+
+func newScopeSequence(src Sequence, variables map[string][]Item, namespace map[string]Builtin, depth int) *ScopeSequence {
+	return &ScopeSequence{Source: src, Variables: variables, Namespace: namespace, Depth: depth}
+}
+
+func (s *ScopeSequence) Next(ctx *Context) (bool, error) {
+	oldVariables, oldNamespace, oldDepth := ctx.Variables, ctx.Namespace, ctx.CallDepth
+	ctx.Variables, ctx.Namespace, ctx.CallDepth = s.Variables, s.Namespace, s.Depth
+	hasNext, err := s.Source.Next(ctx)
+	ctx.Variables, ctx.Namespace, ctx.CallDepth = oldVariables, oldNamespace, oldDepth
+	return hasNext, err
+}
+
+func (s *ScopeSequence) Value() Item {
+	return s.Source.Value()
+}
+
+/*
+ArgumentSequence checks the items of an argument to a builtin against its
+parameter (see Param) as the builtin reads them. Like the rest of DPath, the
+check is lazy: a builtin which only looks at the first item of an argument
+won't find out whether there are too many.
+*/
+type ArgumentSequence struct {
+	Source   Sequence
+	Function string
+	Param    *Param
+	Count    int
+}
+
+/*
+Return an argument sequence, or the source itself if its parameter allows
+anything.
+*/
+func newArgumentSequence(function string, param *Param, src Sequence) Sequence {
+	if param.Type == "item" && param.Occurrence == "*" {
+		return src
+	}
+	return &ArgumentSequence{Source: src, Function: function, Param: param}
+}
+
+func (s *ArgumentSequence) Next(ctx *Context) (bool, error) {
+	hasNext, err := s.Source.Next(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !hasNext {
+		if s.Count == 0 && (s.Param.Occurrence == "" || s.Param.Occurrence == "+") {
+			return false, fmt.Errorf("in call to %s, $%s must not be empty", s.Function, s.Param.Name)
+		}
+		return false, nil
+	}
+	s.Count++
+	if s.Count == 2 && (s.Param.Occurrence == "" || s.Param.Occurrence == "?") {
+		return false, fmt.Errorf("in call to %s, $%s must be a single item", s.Function, s.Param.Name)
+	}
+	if item := s.Source.Value(); !matchesType(item, s.Param.Type) {
+		return false, fmt.Errorf(
+			"in call to %s, $%s must be of type %s, not %s",
+			s.Function, s.Param.Name, s.Param.Type, item.TypeName(),
+		)
+	}
+	return true, nil
+}
+
+func (s *ArgumentSequence) Value() Item {
+	return s.Source.Value()
+}
+
+/*
+LineSequence returns the lines of a file as LineItems. The file is read a block
+at a time as lines are needed, so only the current line and the rest of its
+block are held in memory. The file is opened for each block and closed again
+right away, since a sequence may be abandoned before its end (e.g. by a
+predicate which only needs one line), and nothing would close it then. Files
+which can seek pick up where the last block ended, so each is read only once.
+
+To keep memory bounded, lines longer than maxLineLength are cut short. When
+SkipBinary is true, a file whose first block contains a NUL byte is taken to be
+binary (as grep does), and it has no lines at all.
+*/
+type LineSequence struct {
+	File       *FileItem
+	SkipBinary bool
+	Offset     int64
+	Buffer     []byte
+	EOF        bool
+	Skipping   bool
+	Number     int64
+	Current    *LineItem
+}
+
+/*
+How much of a file LineSequence reads at once, and the most of a line it keeps.
+*/
+const (
+	lineBlockSize = 32 * 1024
+	maxLineLength = 1024 * 1024
+)
+
+/*
+Return a sequence of the lines of a file.
+*/
+func newLineSequence(file *FileItem) *LineSequence {
+	return &LineSequence{File: file, SkipBinary: false}
+}
+
+/*
+Return a sequence of the lines of a file, or an empty sequence if the file is
+binary.
+*/
+func newTextLineSequence(file *FileItem) *LineSequence {
+	return &LineSequence{File: file, SkipBinary: true}
+}
+
+/*
+Read the next block of the file into the buffer, opening the file at the offset
+where the last block ended and closing it again afterwards.
+*/
+func (s *LineSequence) readBlock(ctx *Context) error {
+	f, err := openFile(ctx, s.File.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if s.Offset > 0 {
+		if seeker, ok := f.(io.Seeker); ok {
+			_, err = seeker.Seek(s.Offset, io.SeekStart)
+		} else {
+			_, err = io.CopyN(ioutil.Discard, f, s.Offset)
+		}
+		if err == io.EOF {
+			// The file got shorter since the last block.
+			s.EOF = true
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	block := make([]byte, lineBlockSize)
+	n, err := io.ReadFull(f, block)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		s.EOF = true
+	} else if err != nil {
+		return err
+	}
+	if s.SkipBinary && s.Offset == 0 && bytes.IndexByte(block[:n], 0) >= 0 {
+		s.EOF = true
+		return nil
+	}
+	s.Offset += int64(n)
+	s.Buffer = append(s.Buffer, block[:n]...)
+	return nil
+}
+
+func (s *LineSequence) Next(ctx *Context) (bool, error) {
+	for {
+		if s.Skipping {
+			// Throw away the rest of a line that was too long.
+			if i := bytes.IndexByte(s.Buffer, '\n'); i >= 0 {
+				s.Buffer = s.Buffer[i+1:]
+				s.Skipping = false
+			} else {
+				s.Buffer = s.Buffer[:0]
+			}
+		}
+		if !s.Skipping {
+			if i := bytes.IndexByte(s.Buffer, '\n'); i >= 0 {
+				s.setLine(s.Buffer[:i])
+				s.Buffer = s.Buffer[i+1:]
+				return true, nil
+			} else if len(s.Buffer) >= maxLineLength {
+				s.setLine(s.Buffer[:maxLineLength])
+				s.Buffer = s.Buffer[maxLineLength:]
+				s.Skipping = true
+				return true, nil
+			} else if s.EOF && len(s.Buffer) > 0 {
+				// The last line may not end with a newline.
+				s.setLine(s.Buffer)
+				s.Buffer = nil
+				return true, nil
+			}
+		}
+		if s.EOF {
+			s.Current = nil
+			return false, nil
+		}
+		if err := s.readBlock(ctx); err != nil {
+			s.Current = nil
+			return false, err
+		}
+	}
+}
+
+/*
+Make the current line from its text, removing any carriage return at the end.
+*/
+func (s *LineSequence) setLine(text []byte) {
+	s.Number++
+	text = bytes.TrimSuffix(text, []byte("\r"))
+	s.Current = newLineItem(s.File, s.Number, string(text))
+}
+
+func (s *LineSequence) Value() Item {
+	if s.Current != nil {
+		return s.Current
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+//go:build linux
+// +build linux
+
+/*
+stat_linux.go contains the attributes which come from stat() on Linux.
+*/
+
+package dpath
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+/*
+Owner and group names, by ID, so that we only look each of them up once. Queries
+may run concurrently, so namesLock guards both.
+*/
+var userNames = map[uint32]string{}
+var groupNames = map[uint32]string{}
+var namesLock sync.Mutex
+
+/*
+Return a cached name, and whether there was one.
+*/
+func cachedName(names map[uint32]string, id uint32) (string, bool) {
+	namesLock.Lock()
+	defer namesLock.Unlock()
+	name, ok := names[id]
+	return name, ok
+}
+
+/*
+Add a name to a cache.
+*/
+func cacheName(names map[uint32]string, id uint32, name string) {
+	namesLock.Lock()
+	defer namesLock.Unlock()
+	names[id] = name
+}
+
+/*
+Return the name of a user, or its ID as a string if it has no name.
+*/
+func lookupUserName(uid uint32) string {
+	if name, ok := cachedName(userNames, uid); ok {
+		return name
+	}
+	name := strconv.FormatUint(uint64(uid), 10)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	cacheName(userNames, uid, name)
+	return name
+}
+
+/*
+Return the name of a group, or its ID as a string if it has no name.
+*/
+func lookupGroupName(gid uint32) string {
+	if name, ok := cachedName(groupNames, gid); ok {
+		return name
+	}
+	name := strconv.FormatUint(uint64(gid), 10)
+	if g, err := user.LookupGroupId(name); err == nil {
+		name = g.Name
+	}
+	cacheName(groupNames, gid, name)
+	return name
+}
+
+/*
+Return the device and inode number of a file as a string, and true, or false if
+its info has no Stat_t.
+*/
+func fileIdentity(info os.FileInfo) (string, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%d:%d", st.Dev, st.Ino), true
+}
+
+/*
+Return the value of an attribute which comes from the syscall.Stat_t in a
+file's info, or nil if there is no such attribute (or no Stat_t).
+*/
+func statAttribute(file *FileItem, name string) Item {
+	st, ok := file.Info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	switch name {
+	case "atime":
+		return newDateTimeItem(time.Unix(int64(st.Atim.Sec), int64(st.Atim.Nsec)))
+	case "ctime":
+		return newDateTimeItem(time.Unix(int64(st.Ctim.Sec), int64(st.Ctim.Nsec)))
+	case "mode":
+		return newIntegerItem(int64(st.Mode))
+	case "uid":
+		return newIntegerItem(int64(st.Uid))
+	case "gid":
+		return newIntegerItem(int64(st.Gid))
+	case "owner":
+		return newStringItem(lookupUserName(st.Uid))
+	case "group":
+		return newStringItem(lookupGroupName(st.Gid))
+	case "inode":
+		return newIntegerItem(int64(st.Ino))
+	case "nlink":
+		return newIntegerItem(int64(st.Nlink))
+	case "dev":
+		return newIntegerItem(int64(st.Dev))
+	case "blocks":
+		return newIntegerItem(int64(st.Blocks))
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,48 @@
+//go:build linux
+// +build linux
+
+package dpath
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+func TestStatAttributes(t *testing.T) {
+	f, err := ioutil.TempFile("", "dpath")
+	assert.Nil(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	file, err := newFileItem(MockDefaultContext(), f.Name())
+	assert.Nil(t, err)
+	st := file.Info.Sys().(*syscall.Stat_t)
+
+	assert.Equal(t, newIntegerItem(int64(os.Getuid())), statAttribute(file, "uid"))
+	assert.Equal(t, newIntegerItem(int64(st.Ino)), statAttribute(file, "inode"))
+	assert.Equal(t, newIntegerItem(1), statAttribute(file, "nlink"))
+	if u, err := user.Current(); err == nil {
+		assert.Equal(t, newStringItem(u.Username), statAttribute(file, "owner"))
+	}
+	assert.Nil(t, statAttribute(file, "size"))
+	assert.Nil(t, statAttribute(file, "nonsense"))
+}
+
+func TestNameLookupsConcurrently(t *testing.T) {
+	// Run with -race: queries may look names up from several goroutines.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id uint32) {
+			defer wg.Done()
+			assert.NotEmpty(t, lookupUserName(id))
+			assert.NotEmpty(t, lookupGroupName(id))
+		}(uint32(i % 3))
+	}
+	wg.Wait()
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+/*
+stat_other.go is used on platforms where we don't know the layout of
+syscall.Stat_t, so the only attributes are those from os.FileInfo.
+*/
+
+package dpath
+
+import "os"
+
+func fileIdentity(info os.FileInfo) (string, bool) {
+	return "", false
+}
+
+func statAttribute(file *FileItem, name string) Item {
+	return nil
+}
@@ -8,33 +8,17 @@ pdflatex report
 popd
 
 cp report/report.pdf Brennan.Final.XPathFilesystem.pdf
-go build
-mv dpath dpath.linux
+go build -o dpath.linux ./cmd/dpath
 rm Brennan.Submission.XPathFilesystem.zip
 
+# Every source and test file tracked by git, so that the zip builds on its own.
 zip Brennan.Submission.XPathFilesystem.zip \
     Brennan.Final.XPathFilesystem.pdf \
     README.EECS433.md \
     GUIDE.md \
     SYNTAX.md \
-    axis.go \
-    dpath.nex \
-    dpath.nn.go \
-    dpath.y \
-    error.go \
-    eval_test.go \
-    item.go \
-    lexer_test.go \
-    lib.go \
-    lib_test.go \
-    main.go \
-    parser_test.go \
-    sequence.go \
-    testutil.go \
-    tree.go \
-    util.go \
-    y.go \
-    dpath.linux
+    dpath.linux \
+    $(git ls-files go.mod go.sum '*.go' '*.nex' '*.y')
 
 rm Brennan.Final.XPathFilesystem.pdf
 rm dpath.linux
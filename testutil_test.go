@@ -2,7 +2,7 @@
 testutil.go contains utility functions and mocks for testing DPath
 */
 
-package main
+package dpath
 
 import (
 	"github.com/stretchr/testify/assert"
@@ -79,13 +79,25 @@ func MockDefaultContext() *Context {
 		"descendant-or-self": &MockAxis{AxisName: "descendant-or-self"},
 		"ancestor":           &MockAxis{AxisName: "ancestor"},
 		"ancestor-or-self":   &MockAxis{AxisName: "ancestor-or-self"},
+		"following-sibling":  &MockAxis{AxisName: "following-sibling"},
+		"preceding-sibling":  &MockAxis{AxisName: "preceding-sibling"},
+		"sibling":            &MockAxis{AxisName: "sibling"},
+		"following":          &MockAxis{AxisName: "following"},
+		"preceding":          &MockAxis{AxisName: "preceding"},
+		"self":               &MockAxis{AxisName: "self"},
+		"link-target":        &MockAxis{AxisName: "link-target"},
+		"line":               &MockAxis{AxisName: "line"},
+		"content":            &MockAxis{AxisName: "content"},
 		"attribute":          &MockAxis{AxisName: "attribute"},
 	}
 	return &Context{
-		Axes:        axes,
-		ContextItem: MockFile("/MockedDir", "MockedDir", true),
-		CurrentAxis: axes["child"],
-		Namespace:   DefaultNamespace(),
+		Axes:            axes,
+		ContextItem:     MockFile("/MockedDir", "MockedDir", true),
+		ContextPosition: 1,
+		ContextSize:     singletonContextSize,
+		CurrentAxis:     axes["child"],
+		Namespace:       DefaultNamespace(),
+		Variables:       map[string][]Item{},
 	}
 }
 
@@ -4,12 +4,13 @@ are used heavily within the parser itself (dpath.y) and also do some of the heav
 lifting of evaluation.
 */
 
-package main
+package dpath
 
 import (
 	"errors"
 	"fmt"
 	"io"
+	"path"
 	"strconv"
 	"strings"
 )
@@ -17,7 +18,7 @@ import (
 // The following comments instruct go's build system on how to generate
 // the lexer and parser.
 //go:generate nex dpath.nex
-//go:generate go tool yacc dpath.y
+//go:generate goyacc -o y.go dpath.y
 
 /*
 ParseTree is an interface that allows us to easily evaluate and print out code.
@@ -73,6 +74,24 @@ func (bt *BinopTree) Evaluate(ctx *Context) (Sequence, error) {
 		return GeneralComparison(ctx, left, right, CmpGe)
 	case ">":
 		return GeneralComparison(ctx, left, right, CmpGt)
+	case "union":
+		if left, err = inDocumentOrder(ctx, bt.Left, left); err != nil {
+			return nil, err
+		}
+		if right, err = inDocumentOrder(ctx, bt.Right, right); err != nil {
+			return nil, err
+		}
+		return FileUnion(ctx, left, right)
+	case "intersect":
+		if left, err = inDocumentOrder(ctx, bt.Left, left); err != nil {
+			return nil, err
+		}
+		return FileIntersect(ctx, left, right)
+	case "except":
+		if left, err = inDocumentOrder(ctx, bt.Left, left); err != nil {
+			return nil, err
+		}
+		return FileExcept(ctx, left, right)
 	}
 
 	// now we get singletons from sequences
@@ -82,6 +101,7 @@ func (bt *BinopTree) Evaluate(ctx *Context) (Sequence, error) {
 	if rightItem, err = getSingleItem(ctx, right); err != nil {
 		return nil, err
 	}
+	leftItem, rightItem = atomize(leftItem), atomize(rightItem)
 
 	// dispatch item operators
 	switch bt.Operator {
@@ -162,6 +182,7 @@ func (ut *UnopTree) Evaluate(ctx *Context) (Sequence, error) {
 	if err != nil {
 		return nil, err
 	}
+	item = atomize(item)
 	if item.TypeName() != TYPE_INTEGER && item.TypeName() != TYPE_DOUBLE {
 		return nil, errors.New("unary operator expects numeric type")
 	}
@@ -211,6 +232,17 @@ func newDoubleTree(num string) *LiteralTree {
 	return &LiteralTree{Type: TYPE_DOUBLE, DoubleValue: flt}
 }
 
+/*
+Size literals (like 4KiB) are just integers, so that they compare with @size.
+*/
+func newSizeTree(size string) (*LiteralTree, error) {
+	bytes, err := parseSizeLiteral(size)
+	if err != nil {
+		return nil, err
+	}
+	return &LiteralTree{Type: TYPE_INTEGER, IntegerValue: bytes}, nil
+}
+
 func (lt *LiteralTree) Evaluate(ctx *Context) (Sequence, error) {
 	switch lt.Type {
 	case TYPE_STRING:
@@ -263,11 +295,8 @@ func execBuiltin(ctx *Context, name string, args ...ParseTree) (Sequence, error)
 		return nil, errors.New("builtin function " + name + " not found.")
 	}
 
-	if builtin.NumArgs >= 0 && len(args) != builtin.NumArgs {
-		return nil, errors.New(fmt.Sprintf(
-			"in call to %s, expected %d args, got %d",
-			name, builtin.NumArgs, len(args),
-		))
+	if err = builtin.checkArity(len(args)); err != nil {
+		return nil, err
 	}
 
 	arguments := make([]Sequence, len(args))
@@ -276,6 +305,9 @@ func execBuiltin(ctx *Context, name string, args ...ParseTree) (Sequence, error)
 		if err != nil {
 			return nil, err
 		}
+		if param := builtin.param(i); param != nil {
+			arguments[i] = newArgumentSequence(name, param, arguments[i])
+		}
 	}
 	return builtin.Invoke(ctx, arguments...)
 }
@@ -298,6 +330,148 @@ func (ft *FunccallTree) Print(r io.Writer, indent int) error {
 	return nil
 }
 
+/*
+The most declared functions which may be called within each other before a
+query fails. It is far more than a query needs, but well short of the stack.
+*/
+const maxCallDepth = 1000
+
+/*
+FunctionDecl is a function declared at the start of a query, like:
+
+	declare function is-big($f) { $f/@size > 1MB };
+
+Once declared, it is called like any builtin (see execBuiltin()).
+*/
+type FunctionDecl struct {
+	Name   string
+	Params []string
+	Body   ParseTree
+}
+
+func newFunctionDecl(name string, params []string, body ParseTree) *FunctionDecl {
+	return &FunctionDecl{Name: name, Params: params, Body: body}
+}
+
+/*
+Return a builtin which evaluates the body of a declared function. Like XQuery,
+the body can see its parameters and the variables which were bound when it was
+declared (globals), but not the variables of whoever calls it. It uses the
+namespace of the query which declared it, so functions may call each other, or
+themselves. The context item is the one at the point of the call.
+*/
+func (fd *FunctionDecl) builtin(namespace map[string]Builtin, globals map[string][]Item) Builtin {
+	params := make([]Param, len(fd.Params))
+	for i, name := range fd.Params {
+		params[i] = Param{Name: name, Type: "item", Occurrence: "*"}
+	}
+	return Builtin{
+		Name:    fd.Name,
+		Params:  params,
+		MinArgs: len(params),
+		MaxArgs: len(params),
+		Returns: "item*",
+		Doc:     "Declared in the query.",
+		Invoke: func(ctx *Context, args ...Sequence) (Sequence, error) {
+			if ctx.CallDepth >= maxCallDepth {
+				return nil, fmt.Errorf("%s: too many nested function calls (more than %d)",
+					fd.Name, maxCallDepth)
+			}
+			variables := make(map[string][]Item, len(globals)+len(args))
+			for name, value := range globals {
+				variables[name] = value
+			}
+			for i, arg := range args {
+				// Arguments are evaluated in the scope of the caller, so they're
+				// read in full before the function's scope takes over.
+				value, err := seqToSlice(arg, ctx)
+				if err != nil {
+					return nil, err
+				}
+				variables[fd.Params[i]] = value
+			}
+			return evaluateInScope(ctx, fd.Body, variables, namespace, ctx.CallDepth+1)
+		},
+	}
+}
+
+func (fd *FunctionDecl) Print(r io.Writer, indent int) error {
+	indentStr := getIndent(indent)
+	params := make([]string, len(fd.Params))
+	for i, name := range fd.Params {
+		params[i] = "$" + name
+	}
+	header := "DECLARE FUNCTION " + fd.Name + "(" + strings.Join(params, ", ") + ")\n"
+	if _, e := io.WriteString(r, indentStr+header); e != nil {
+		return e
+	}
+	return fd.Body.Print(r, indent+1)
+}
+
+/*
+ModuleTree is a query which starts with function declarations (its prolog). The
+functions are added to a copy of the namespace, which is used to evaluate the
+rest of the query.
+*/
+type ModuleTree struct {
+	Functions []*FunctionDecl
+	Query     ParseTree
+}
+
+func newModuleTree(functions []*FunctionDecl, query ParseTree) *ModuleTree {
+	return &ModuleTree{Functions: functions, Query: query}
+}
+
+func (mt *ModuleTree) Evaluate(ctx *Context) (Sequence, error) {
+	namespace := make(map[string]Builtin, len(ctx.Namespace)+len(mt.Functions))
+	for name, builtin := range ctx.Namespace {
+		namespace[name] = builtin
+	}
+	globals := make(map[string][]Item, len(ctx.Variables))
+	for name, value := range ctx.Variables {
+		globals[name] = value
+	}
+	for _, fd := range mt.Functions {
+		if _, ok := namespace[fd.Name]; ok {
+			return nil, fmt.Errorf("function %s is already defined", fd.Name)
+		}
+		seen := map[string]bool{}
+		for _, param := range fd.Params {
+			if seen[param] {
+				return nil, fmt.Errorf("function %s has two parameters named $%s", fd.Name, param)
+			}
+			seen[param] = true
+		}
+		namespace[fd.Name] = fd.builtin(namespace, globals)
+	}
+	return evaluateInScope(ctx, mt.Query, ctx.Variables, namespace, ctx.CallDepth)
+}
+
+func (mt *ModuleTree) Print(r io.Writer, indent int) error {
+	for _, fd := range mt.Functions {
+		if e := fd.Print(r, indent); e != nil {
+			return e
+		}
+	}
+	return mt.Query.Print(r, indent)
+}
+
+/*
+Evaluate a tree with different variables, functions and call depth in scope,
+returning a sequence which puts them back in place whenever it is advanced (see
+ScopeSequence).
+*/
+func evaluateInScope(ctx *Context, tree ParseTree, variables map[string][]Item, namespace map[string]Builtin, depth int) (Sequence, error) {
+	oldVariables, oldNamespace, oldDepth := ctx.Variables, ctx.Namespace, ctx.CallDepth
+	ctx.Variables, ctx.Namespace, ctx.CallDepth = variables, namespace, depth
+	seq, err := tree.Evaluate(ctx)
+	ctx.Variables, ctx.Namespace, ctx.CallDepth = oldVariables, oldNamespace, oldDepth
+	if err != nil {
+		return nil, err
+	}
+	return newScopeSequence(seq, variables, namespace, depth), nil
+}
+
 /*
 ContextItemTree represents the use of . in an expression.
 */
@@ -339,15 +513,58 @@ func (et *EmptySequenceTree) Print(r io.Writer, indent int) error {
 }
 
 /*
-FilteredSequenceTree represents a predicate after an expression.
+FilteredSequenceTree represents a predicate after an expression. Step is set
+when the predicates belong to a step expression (like ancestor::*[1]) rather
+than to some other expression (like (ancestor::*)[1]). This matters because
+positions within the predicates of a step along a reverse axis are counted
+backwards.
 */
 type FilteredSequenceTree struct {
 	Source ParseTree
 	Filter []ParseTree
+	Step   bool
 }
 
 func newFilteredSequenceTree(s ParseTree, f []ParseTree) *FilteredSequenceTree {
-	return &FilteredSequenceTree{Source: s, Filter: f}
+	return &FilteredSequenceTree{Source: s, Filter: f, Step: false}
+}
+
+func newFilteredStepTree(s ParseTree, f []ParseTree) *FilteredSequenceTree {
+	return &FilteredSequenceTree{Source: s, Filter: f, Step: true}
+}
+
+/*
+Return the axis that a step expression (see isStep()) will travel along, or nil
+for the context item expression.
+*/
+func stepAxis(ctx *Context, step ParseTree) Axis {
+	switch t := step.(type) {
+	case *FilteredSequenceTree:
+		return stepAxis(ctx, t.Source)
+	case *ContextItemTree:
+		return nil
+	case *AxisTree:
+		return ctx.Axes[t.Axis]
+	case *KindTree:
+		if t.Kind == ".." {
+			return ctx.Axes["parent"]
+		}
+	}
+	return ctx.CurrentAxis
+}
+
+/*
+Reverse a sequence, reading it entirely into memory to do so.
+*/
+func reverseSequence(ctx *Context, seq Sequence) (Sequence, error) {
+	items, err := seqToSlice(seq, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	return newWrapperSequence(items), nil
 }
 
 func (bt *FilteredSequenceTree) Evaluate(ctx *Context) (Sequence, error) {
@@ -355,8 +572,23 @@ func (bt *FilteredSequenceTree) Evaluate(ctx *Context) (Sequence, error) {
 	if err != nil {
 		return nil, err
 	}
-	// BUG(stephen): numeric expressions should index into a sequence
-	return newExpressionFilter(seq, bt.Filter), nil
+	// Axes return items in document order, so reverse axes need to be flipped
+	// around for filtering, and then flipped back.
+	reverse := bt.Step && isReverseAxis(stepAxis(ctx, bt.Source))
+	if reverse {
+		if seq, err = reverseSequence(ctx, seq); err != nil {
+			return nil, err
+		}
+	}
+	// Each predicate filters the output of the one before it, and positions
+	// within a predicate are relative to that output.
+	for _, filter := range bt.Filter {
+		seq = newExpressionFilter(seq, filter)
+	}
+	if reverse {
+		return reverseSequence(ctx, seq)
+	}
+	return seq, nil
 }
 
 func (t *FilteredSequenceTree) Print(r io.Writer, indent int) error {
@@ -405,7 +637,21 @@ func fileDirFilter(ctx *Context, findFiles bool) (Sequence, error) {
 		if !ok {
 			return false
 		}
-		return findFiles != file.Info.IsDir()
+		return findFiles != isDir(ctx, file)
+	}), nil
+}
+
+/*
+Return the symbolic links on the current axis.
+*/
+func symlinkFilter(ctx *Context) (Sequence, error) {
+	seq, err := ctx.CurrentAxis.Iterate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newConditionFilter(seq, func(it Item) bool {
+		file, ok := it.(*FileItem)
+		return ok && isSymlink(file)
 	}), nil
 }
 
@@ -419,6 +665,8 @@ func (bt *KindTree) Evaluate(ctx *Context) (Sequence, error) {
 		return fileDirFilter(ctx, true)
 	case "dir":
 		return fileDirFilter(ctx, false)
+	case "symlink":
+		return symlinkFilter(ctx)
 	default:
 		return nil, errors.New("Not implemented.")
 	}
@@ -451,6 +699,32 @@ func (t *NameTree) Print(r io.Writer, indent int) error {
 	return e
 }
 
+/*
+GlobTree represents a name test containing wildcards, like *.go or [a-c]*.txt.
+The pattern is stored in the syntax of path.Match().
+*/
+type GlobTree struct {
+	Pattern string
+}
+
+func newGlobTree(s string) *GlobTree {
+	// Shells negate character classes with [!...], but path.Match uses [^...].
+	return &GlobTree{Pattern: strings.Replace(s, "[!", "[^", -1)}
+}
+
+func (bt *GlobTree) Evaluate(ctx *Context) (Sequence, error) {
+	if _, err := path.Match(bt.Pattern, ""); err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid pattern %s", bt.Pattern))
+	}
+	return getByPattern(ctx, ctx.CurrentAxis, bt.Pattern)
+}
+
+func (t *GlobTree) Print(r io.Writer, indent int) error {
+	indentStr := getIndent(indent)
+	_, e := io.WriteString(r, indentStr+"Glob("+t.Pattern+")\n")
+	return e
+}
+
 /*
 AxisItem represents a step expression that is prefixed by an axis, such as:
 child::filename
@@ -510,7 +784,7 @@ func (bt *PathTree) Evaluate(ctx *Context) (Sequence, error) {
 	if bt.Rooted {
 		// When the path is rooted, we behave as if the path started with a step
 		// expression that returned the root directory.
-		rootItem, err := newFileItem("/")
+		rootItem, err := newFileItem(ctx, "/")
 		if err != nil {
 			panic("Falied to set root as context item!")
 		}
@@ -523,16 +797,56 @@ func (bt *PathTree) Evaluate(ctx *Context) (Sequence, error) {
 		}
 		pathToIterate = bt.Path[1:]
 	}
+	// Steps return their results in document order, and so do PathSequences.
+	// Anything else needs to be sorted before we know the order of its output.
+	ordered := bt.Rooted || isStep(bt.Path[0])
 	// This "reduces" the path to a chain of PathSequences
 	for _, pathItem := range pathToIterate {
 		if pathItem == nil {
 			pathItem = newAxisTree("descendant-or-self", newKindTree("*"))
 		}
-		Source = newPathSequence(Source, pathItem)
+		forward := isStep(pathItem) && isForwardAxis(stepAxis(ctx, pathItem))
+		Source = newPathSequence(Source, pathItem, ordered && forward)
+		ordered = true
 	}
 	return Source, nil
 }
 
+/*
+Return the result of an operand of a set operator in document order. Steps,
+paths and set operators already give their files in document order, but
+anything else (like "(c, a)") is read in full and sorted.
+*/
+func inDocumentOrder(ctx *Context, t ParseTree, seq Sequence) (Sequence, error) {
+	switch t := t.(type) {
+	case *PathTree:
+		return seq, nil
+	case *BinopTree:
+		switch t.Operator {
+		case "union", "intersect", "except":
+			return seq, nil
+		}
+	}
+	if isStep(t) {
+		return seq, nil
+	}
+	return sortFiles(ctx, seq)
+}
+
+/*
+Return true if the expression is a step expression (from the AxisStep
+production), or the context item expression.
+*/
+func isStep(t ParseTree) bool {
+	switch t := t.(type) {
+	case *AxisTree, *KindTree, *NameTree, *GlobTree, *ContextItemTree:
+		return true
+	case *FilteredSequenceTree:
+		return t.Step
+	}
+	return false
+}
+
 func (pt *PathTree) Print(r io.Writer, indent int) error {
 	var e error
 	indentStr := getIndent(indent)
@@ -599,3 +913,289 @@ func (st *SequenceTree) Print(w io.Writer, indent int) error {
 	}
 	return nil
 }
+
+/*
+VarBinding holds a single variable binding clause, like "$x := expr" within a
+let expression or "$x in expr" within a for expression. It is not a ParseTree
+on its own, but it is used by the trees which bind variables.
+*/
+type VarBinding struct {
+	Name  string
+	Value ParseTree
+}
+
+func newVarBinding(name string, value ParseTree) *VarBinding {
+	return &VarBinding{Name: name, Value: value}
+}
+
+/*
+LetTree binds the result of an expression to a variable, and then evaluates
+the return expression with that variable in scope. A let expression with
+several bindings is held as nested LetTrees.
+*/
+type LetTree struct {
+	Variable string
+	Value    ParseTree
+	Return   ParseTree
+}
+
+/*
+Return a LetTree for the given bindings. Each binding after the first is
+nested inside the return expression of the one before it, so that later
+bindings may refer to earlier ones.
+*/
+func newLetTree(bindings []*VarBinding, ret ParseTree) *LetTree {
+	for i := len(bindings) - 1; i > 0; i-- {
+		ret = &LetTree{
+			Variable: bindings[i].Name, Value: bindings[i].Value, Return: ret,
+		}
+	}
+	return &LetTree{Variable: bindings[0].Name, Value: bindings[0].Value, Return: ret}
+}
+
+func (lt *LetTree) Evaluate(ctx *Context) (Sequence, error) {
+	seq, err := lt.Value.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The value is only evaluated once, so it needs to be stored in full in
+	// order to be referenced any number of times.
+	value, err := seqToSlice(seq, ctx)
+	if err != nil {
+		return nil, err
+	}
+	old := bindVariable(ctx, lt.Variable, value)
+	ret, err := lt.Return.Evaluate(ctx)
+	restoreVariable(ctx, lt.Variable, old)
+	if err != nil {
+		return nil, err
+	}
+	return newBindingSequence(ret, lt.Variable, value), nil
+}
+
+func (lt *LetTree) Print(r io.Writer, indent int) error {
+	var e error
+	indentStr := getIndent(indent)
+	if _, e = io.WriteString(r, indentStr+"LET $"+lt.Variable+" :=\n"); e != nil {
+		return e
+	}
+	if e = lt.Value.Print(r, indent+1); e != nil {
+		return e
+	}
+	if _, e = io.WriteString(r, indentStr+"RETURN\n"); e != nil {
+		return e
+	}
+	return lt.Return.Print(r, indent+1)
+}
+
+/*
+VarRefTree represents a reference to a variable, such as $x.
+*/
+type VarRefTree struct {
+	Name string
+}
+
+func newVarRefTree(name string) *VarRefTree {
+	return &VarRefTree{Name: name}
+}
+
+func (vt *VarRefTree) Evaluate(ctx *Context) (Sequence, error) {
+	value, ok := ctx.Variables[vt.Name]
+	if !ok {
+		return nil, errors.New("variable $" + vt.Name + " is not bound")
+	}
+	return newWrapperSequence(value), nil
+}
+
+func (vt *VarRefTree) Print(r io.Writer, indent int) error {
+	indentStr := getIndent(indent)
+	_, e := io.WriteString(r, indentStr+"$"+vt.Name+"\n")
+	return e
+}
+
+/*
+ForTree represents a for expression, which evaluates its return expression once
+for every item of the input sequence, with a variable bound to that item. As
+with LetTree, several bindings are held as nested ForTrees.
+*/
+type ForTree struct {
+	Variable string
+	Source   ParseTree
+	Return   ParseTree
+}
+
+/*
+Return a ForTree for the given bindings. Each binding after the first is
+nested inside the return expression of the one before it.
+*/
+func newForTree(bindings []*VarBinding, ret ParseTree) *ForTree {
+	for i := len(bindings) - 1; i > 0; i-- {
+		ret = &ForTree{
+			Variable: bindings[i].Name, Source: bindings[i].Value, Return: ret,
+		}
+	}
+	return &ForTree{Variable: bindings[0].Name, Source: bindings[0].Value, Return: ret}
+}
+
+func (ft *ForTree) Evaluate(ctx *Context) (Sequence, error) {
+	src, err := ft.Source.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newForSequence(src, ft.Variable, ft.Return), nil
+}
+
+func (ft *ForTree) Print(r io.Writer, indent int) error {
+	var e error
+	indentStr := getIndent(indent)
+	if _, e = io.WriteString(r, indentStr+"FOR $"+ft.Variable+" IN\n"); e != nil {
+		return e
+	}
+	if e = ft.Source.Print(r, indent+1); e != nil {
+		return e
+	}
+	if _, e = io.WriteString(r, indentStr+"RETURN\n"); e != nil {
+		return e
+	}
+	return ft.Return.Print(r, indent+1)
+}
+
+/*
+IfTree represents a conditional expression. The condition is converted to its
+effective boolean value (see BuiltinBooleanInvoke()), and then only the branch
+which was chosen is evaluated. This doesn't depend on boolean() being in the
+namespace of the context.
+*/
+type IfTree struct {
+	Condition ParseTree
+	Then      ParseTree
+	Else      ParseTree
+}
+
+func newIfTree(cond ParseTree, then ParseTree, els ParseTree) *IfTree {
+	return &IfTree{Condition: cond, Then: then, Else: els}
+}
+
+func (it *IfTree) Evaluate(ctx *Context) (Sequence, error) {
+	cond, err := it.Condition.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := BuiltinBooleanInvoke(ctx, cond)
+	if err != nil {
+		return nil, err
+	}
+	if getBool(panicUnlessOne(ctx, res)) {
+		return it.Then.Evaluate(ctx)
+	} else {
+		return it.Else.Evaluate(ctx)
+	}
+}
+
+func (it *IfTree) Print(r io.Writer, indent int) error {
+	var e error
+	indentStr := getIndent(indent)
+	if _, e = io.WriteString(r, indentStr+"IF\n"); e != nil {
+		return e
+	}
+	if e = it.Condition.Print(r, indent+1); e != nil {
+		return e
+	}
+	if _, e = io.WriteString(r, indentStr+"THEN\n"); e != nil {
+		return e
+	}
+	if e = it.Then.Print(r, indent+1); e != nil {
+		return e
+	}
+	if _, e = io.WriteString(r, indentStr+"ELSE\n"); e != nil {
+		return e
+	}
+	return it.Else.Print(r, indent+1)
+}
+
+/*
+QuantifiedTree represents a "some" or "every" expression. These test whether
+some (or every) item of a sequence satisfies a condition, with a variable bound
+to the item being tested. Several bindings are held as nested QuantifiedTrees.
+Like an IfTree, the condition is converted to its effective boolean value
+directly, with BuiltinBooleanInvoke().
+*/
+type QuantifiedTree struct {
+	Quantifier string
+	Variable   string
+	Source     ParseTree
+	Condition  ParseTree
+}
+
+/*
+Return a QuantifiedTree for the given quantifier ("some" or "every") and
+bindings, nesting each binding after the first within the condition of the one
+before it.
+*/
+func newQuantifiedTree(q string, bindings []*VarBinding, cond ParseTree) *QuantifiedTree {
+	for i := len(bindings) - 1; i > 0; i-- {
+		cond = &QuantifiedTree{
+			Quantifier: q, Variable: bindings[i].Name,
+			Source: bindings[i].Value, Condition: cond,
+		}
+	}
+	return &QuantifiedTree{
+		Quantifier: q, Variable: bindings[0].Name,
+		Source: bindings[0].Value, Condition: cond,
+	}
+}
+
+func (qt *QuantifiedTree) Evaluate(ctx *Context) (Sequence, error) {
+	var hasNext bool
+	src, err := qt.Source.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// "some" stops at the first item which satisfies the condition, and "every"
+	// stops at the first one which doesn't. Either way, the answer is then the
+	// opposite of what it would be if we got through the whole sequence.
+	stopAt := qt.Quantifier == "some"
+	for hasNext, err = src.Next(ctx); hasNext && err == nil; hasNext, err = src.Next(ctx) {
+		old := bindVariable(ctx, qt.Variable, []Item{src.Value()})
+		res, err := qt.satisfied(ctx)
+		restoreVariable(ctx, qt.Variable, old)
+		if err != nil {
+			return nil, err
+		}
+		if getBool(panicUnlessOne(ctx, res)) == stopAt {
+			return newSingletonSequence(newBooleanItem(stopAt)), nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newSingletonSequence(newBooleanItem(!stopAt)), nil
+}
+
+/*
+Evaluate the condition for the item which is bound, returning its effective
+boolean value.
+*/
+func (qt *QuantifiedTree) satisfied(ctx *Context) (Sequence, error) {
+	cond, err := qt.Condition.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return BuiltinBooleanInvoke(ctx, cond)
+}
+
+func (qt *QuantifiedTree) Print(r io.Writer, indent int) error {
+	var e error
+	indentStr := getIndent(indent)
+	header := strings.ToUpper(qt.Quantifier) + " $" + qt.Variable + " IN\n"
+	if _, e = io.WriteString(r, indentStr+header); e != nil {
+		return e
+	}
+	if e = qt.Source.Print(r, indent+1); e != nil {
+		return e
+	}
+	if _, e = io.WriteString(r, indentStr+"SATISFIES\n"); e != nil {
+		return e
+	}
+	return qt.Condition.Print(r, indent+1)
+}
@@ -3,12 +3,15 @@ util.go contains several private utility functions that are useful for making
 code a bit simpler.
 */
 
-package main
+package dpath
 
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"math"
+	"strconv"
+	"strings"
 )
 
 /*
@@ -17,10 +20,10 @@ if there are zero or >1 items in the sequence.
 */
 func getSingleItem(ctx *Context, s Sequence) (Item, error) {
 	r, e := s.Next(ctx)
-	if !r {
-		return nil, errors.New("Expected one value, found none.")
-	} else if e != nil {
+	if e != nil {
 		return nil, e
+	} else if !r {
+		return nil, errors.New("Expected one value, found none.")
 	}
 	item := s.Value()
 	r, e = s.Next(ctx)
@@ -69,7 +72,7 @@ func funcGetString(ctx *Context, s Sequence) (string, error) {
 	} else if !r {
 		return "", nil
 	}
-	item := s.Value()
+	item := atomize(s.Value())
 	r, e = s.Next(ctx)
 	if e != nil {
 		return "", e
@@ -191,6 +194,62 @@ func parseStringLiteral(str string) string {
 	return buffer.String()
 }
 
+/*
+Prefixes of the size units, in increasing order of magnitude.
+*/
+const sizePrefixes = "KMGTPE"
+
+/*
+Return the number of bytes in a size literal, like 100MB, 4KiB or 1.5G. Units
+with an "i" are powers of 1024, and the rest are powers of 1000. The trailing B
+is optional (except for plain bytes). Sizes which are too big to be an integer,
+like 16EiB, are an error.
+*/
+func parseSizeLiteral(str string) (int64, error) {
+	end := strings.IndexFunc(str, func(r rune) bool {
+		return r != '.' && (r < '0' || r > '9')
+	})
+	unit := strings.TrimSuffix(str[end:], "B")
+	value, _ := strconv.ParseFloat(str[:end], 64)
+	if unit != "" {
+		base := 1000.0
+		if strings.HasSuffix(unit, "i") {
+			base = 1024.0
+		}
+		exponent := strings.Index(sizePrefixes, strings.ToUpper(unit[:1])) + 1
+		value *= math.Pow(base, float64(exponent))
+	}
+	rounded := math.Floor(value + 0.5)
+	if rounded >= math.MaxInt64 {
+		return 0, fmt.Errorf("Size literal %s is too large.", str)
+	}
+	return int64(rounded), nil
+}
+
+/*
+Format a number of bytes in a human readable way, like "1.5 MiB". The units are
+powers of 1024, unless si is true, in which case they are powers of 1000 (like
+"1.6 MB").
+*/
+func formatSize(size int64, si bool) string {
+	base, suffix := 1024.0, "iB"
+	if si {
+		base, suffix = 1000.0, "B"
+	}
+	value := float64(size)
+	exponent := 0
+	for math.Abs(value) >= base && exponent < len(sizePrefixes) {
+		value /= base
+		exponent++
+	}
+	if exponent == 0 {
+		return strconv.FormatInt(size, 10) + " B"
+	}
+	number := strconv.FormatFloat(value, 'f', 1, 64)
+	number = strings.TrimSuffix(number, ".0")
+	return number + " " + sizePrefixes[exponent-1:exponent] + suffix
+}
+
 /*
 Convert a sequence to a slice of Items.
 */
@@ -203,3 +262,29 @@ func seqToSlice(seq Sequence, ctx *Context) ([]Item, error) {
 	}
 	return items, err
 }
+
+/*
+Bind a variable name to a value within the context. The previous value is
+returned (nil if the variable was not bound), and it should be handed back to
+restoreVariable() once the binding goes out of scope.
+*/
+func bindVariable(ctx *Context, name string, value []Item) []Item {
+	old := ctx.Variables[name]
+	if value == nil {
+		value = []Item{}
+	}
+	ctx.Variables[name] = value
+	return old
+}
+
+/*
+Restore a variable to the value returned by bindVariable(), removing it from
+the context if it was not previously bound.
+*/
+func restoreVariable(ctx *Context, name string, old []Item) {
+	if old == nil {
+		delete(ctx.Variables, name)
+	} else {
+		ctx.Variables[name] = old
+	}
+}
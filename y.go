@@ -1,58 +1,87 @@
+// Code generated by goyacc -o y.go dpath.y. DO NOT EDIT.
+
 //line dpath.y:2
-package main
+package dpath
 
 import __yyfmt__ "fmt"
 
 //line dpath.y:2
+
 //line dpath.y:5
 type yySymType struct {
-	yys  int
-	tree ParseTree
-	str  string
-	num  int
-	args []ParseTree
+	yys      int
+	tree     ParseTree
+	str      string
+	num      int
+	args     []ParseTree
+	binding  *VarBinding
+	bindings []*VarBinding
+	decl     *FunctionDecl
+	decls    []*FunctionDecl
+	names    []string
 }
 
 const STRING_LITERAL = 57346
 const INTEGER_LITERAL = 57347
 const DECIMAL_LITERAL = 57348
 const DOUBLE_LITERAL = 57349
-const QNAME = 57350
-const OR = 57351
-const AND = 57352
-const DIVIDE = 57353
-const INTEGER_DIVIDE = 57354
-const MODULUS = 57355
-const VEQ = 57356
-const VNE = 57357
-const VLT = 57358
-const VLE = 57359
-const VGT = 57360
-const VGE = 57361
-const FILE = 57362
-const DIR = 57363
-const TO = 57364
-const AXIS = 57365
-const DOLLAR = 57366
-const POUND = 57367
-const LPAREN = 57368
-const RPAREN = 57369
-const LBRACKET = 57370
-const RBRACKET = 57371
-const COMMA = 57372
-const PLUS = 57373
-const MINUS = 57374
-const MULTIPLY = 57375
-const SLASH = 57376
-const GEQ = 57377
-const GNE = 57378
-const GLT = 57379
-const GLE = 57380
-const GGT = 57381
-const GGE = 57382
-const ATTR = 57383
-const DOTDOT = 57384
-const DOT = 57385
+const SIZE_LITERAL = 57350
+const QNAME = 57351
+const GLOB = 57352
+const OR = 57353
+const AND = 57354
+const DIVIDE = 57355
+const INTEGER_DIVIDE = 57356
+const MODULUS = 57357
+const VEQ = 57358
+const VNE = 57359
+const VLT = 57360
+const VLE = 57361
+const VGT = 57362
+const VGE = 57363
+const FILE = 57364
+const DIR = 57365
+const SYMLINK = 57366
+const TO = 57367
+const AXIS = 57368
+const LET = 57369
+const FOR = 57370
+const IN = 57371
+const IF = 57372
+const THEN = 57373
+const ELSE = 57374
+const SOME = 57375
+const EVERY = 57376
+const SATISFIES = 57377
+const UNION = 57378
+const INTERSECT = 57379
+const EXCEPT = 57380
+const RETURN = 57381
+const ASSIGN = 57382
+const DECLARE_FUNCTION = 57383
+const DOLLAR = 57384
+const POUND = 57385
+const LPAREN = 57386
+const RPAREN = 57387
+const LBRACKET = 57388
+const RBRACKET = 57389
+const COMMA = 57390
+const SEMICOLON = 57391
+const LBRACE = 57392
+const RBRACE = 57393
+const PLUS = 57394
+const MINUS = 57395
+const MULTIPLY = 57396
+const SLASH = 57397
+const GEQ = 57398
+const GNE = 57399
+const GLT = 57400
+const GLE = 57401
+const GGT = 57402
+const GGE = 57403
+const ATTR = 57404
+const DOTDOT = 57405
+const DOT = 57406
 
 var yyToknames = [...]string{
 	"$end",
@@ -62,7 +91,9 @@ var yyToknames = [...]string{
 	"INTEGER_LITERAL",
 	"DECIMAL_LITERAL",
 	"DOUBLE_LITERAL",
+	"SIZE_LITERAL",
 	"QNAME",
+	"GLOB",
 	"OR",
 	"AND",
 	"DIVIDE",
@@ -76,8 +107,24 @@ var yyToknames = [...]string{
 	"VGE",
 	"FILE",
 	"DIR",
+	"SYMLINK",
 	"TO",
 	"AXIS",
+	"LET",
+	"FOR",
+	"IN",
+	"IF",
+	"THEN",
+	"ELSE",
+	"SOME",
+	"EVERY",
+	"SATISFIES",
+	"UNION",
+	"INTERSECT",
+	"EXCEPT",
+	"RETURN",
+	"ASSIGN",
+	"DECLARE_FUNCTION",
 	"DOLLAR",
 	"POUND",
 	"LPAREN",
@@ -85,6 +132,9 @@ var yyToknames = [...]string{
 	"LBRACKET",
 	"RBRACKET",
 	"COMMA",
+	"SEMICOLON",
+	"LBRACE",
+	"RBRACE",
 	"PLUS",
 	"MINUS",
 	"MULTIPLY",
@@ -99,145 +149,208 @@ var yyToknames = [...]string{
 	"DOTDOT",
 	"DOT",
 }
+
 var yyStatenames = [...]string{}
 
 const yyEofCode = 1
 const yyErrCode = 2
 const yyInitialStackSize = 16
 
-//line dpath.y:235
+//line dpath.y:346
 
 //line yacctab:1
-var yyExca = [...]int{
+var yyExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
 }
 
-const yyNprod = 79
 const yyPrivate = 57344
 
-var yyTokenNames []string
-var yyStates []string
-
-const yyLast = 258
-
-var yyAct = [...]int{
-
-	3, 17, 25, 15, 72, 9, 7, 2, 8, 6,
-	68, 32, 33, 34, 35, 22, 5, 60, 61, 10,
-	69, 59, 42, 63, 64, 65, 77, 38, 39, 73,
-	60, 61, 41, 36, 102, 109, 42, 107, 12, 13,
-	40, 16, 106, 84, 79, 62, 78, 82, 23, 24,
-	37, 81, 87, 88, 86, 44, 43, 83, 38, 39,
-	85, 110, 105, 41, 111, 42, 90, 91, 89, 75,
-	96, 40, 76, 71, 98, 26, 99, 104, 101, 99,
-	11, 100, 92, 93, 94, 95, 32, 33, 34, 35,
-	22, 103, 29, 66, 67, 74, 28, 27, 21, 108,
-	19, 30, 38, 39, 31, 20, 18, 41, 36, 80,
-	14, 46, 112, 12, 13, 40, 16, 32, 33, 34,
-	35, 22, 45, 23, 24, 37, 4, 1, 0, 0,
-	0, 0, 0, 38, 39, 0, 0, 0, 41, 36,
-	0, 0, 0, 0, 12, 13, 40, 16, 32, 33,
-	34, 35, 22, 0, 23, 24, 37, 0, 0, 32,
-	33, 34, 35, 22, 38, 39, 0, 0, 0, 41,
-	36, 0, 0, 0, 0, 38, 39, 40, 97, 0,
-	41, 36, 0, 0, 0, 23, 24, 37, 40, 70,
-	32, 33, 34, 35, 22, 0, 23, 24, 37, 0,
-	0, 32, 33, 34, 35, 22, 38, 39, 0, 0,
-	0, 41, 36, 0, 0, 0, 0, 38, 39, 40,
-	16, 0, 41, 36, 0, 0, 0, 23, 24, 37,
-	40, 47, 48, 49, 50, 51, 52, 0, 23, 24,
-	37, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 53, 54, 55, 56, 57, 58,
+const yyLast = 569
+
+var yyAct = [...]uint8{
+	4, 2, 31, 69, 39, 62, 72, 107, 24, 21,
+	29, 103, 23, 20, 19, 18, 12, 91, 185, 95,
+	96, 97, 122, 22, 92, 93, 176, 173, 61, 47,
+	48, 49, 50, 51, 36, 59, 61, 61, 61, 192,
+	188, 104, 112, 177, 92, 93, 178, 55, 56, 57,
+	113, 59, 13, 14, 114, 15, 64, 129, 16, 17,
+	94, 108, 121, 55, 56, 57, 130, 54, 60, 52,
+	153, 174, 169, 134, 175, 61, 132, 26, 27, 58,
+	30, 126, 123, 125, 60, 133, 130, 37, 38, 53,
+	127, 156, 135, 136, 61, 58, 162, 159, 130, 160,
+	158, 157, 138, 139, 110, 137, 147, 124, 145, 146,
+	151, 144, 155, 119, 150, 152, 149, 150, 140, 141,
+	142, 143, 111, 118, 117, 74, 186, 163, 73, 70,
+	166, 164, 165, 98, 170, 171, 106, 167, 99, 100,
+	191, 182, 168, 25, 67, 65, 190, 179, 131, 128,
+	71, 172, 116, 120, 66, 5, 40, 154, 43, 63,
+	79, 80, 81, 82, 83, 84, 180, 75, 76, 181,
+	101, 102, 109, 44, 42, 41, 183, 35, 184, 33,
+	45, 46, 34, 187, 32, 28, 78, 189, 77, 6,
+	10, 9, 193, 47, 48, 49, 50, 51, 36, 59,
+	85, 86, 87, 88, 89, 90, 8, 68, 7, 161,
+	3, 55, 56, 57, 1, 0, 13, 14, 0, 15,
+	0, 0, 16, 17, 0, 0, 0, 0, 0, 0,
+	0, 54, 60, 52, 115, 0, 0, 0, 0, 0,
+	0, 26, 27, 58, 30, 0, 0, 0, 0, 0,
+	0, 37, 38, 53, 47, 48, 49, 50, 51, 36,
+	59, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 55, 56, 57, 0, 0, 13, 14, 0,
+	15, 0, 0, 16, 17, 0, 0, 0, 0, 0,
+	0, 11, 54, 60, 52, 0, 0, 0, 0, 0,
+	0, 0, 26, 27, 58, 30, 0, 0, 0, 0,
+	0, 0, 37, 38, 53, 47, 48, 49, 50, 51,
+	36, 59, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 55, 56, 57, 0, 0, 13, 14,
+	0, 15, 0, 0, 16, 17, 0, 0, 0, 0,
+	0, 0, 0, 54, 60, 52, 0, 0, 0, 0,
+	0, 0, 0, 26, 27, 58, 30, 0, 0, 0,
+	0, 0, 0, 37, 38, 53, 47, 48, 49, 50,
+	51, 36, 59, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 55, 56, 57, 0, 0, 47,
+	48, 49, 50, 51, 36, 59, 0, 0, 0, 0,
+	0, 0, 0, 0, 54, 60, 52, 55, 56, 57,
+	0, 0, 0, 0, 26, 27, 58, 30, 0, 0,
+	0, 0, 0, 0, 37, 38, 53, 54, 60, 52,
+	0, 0, 47, 48, 49, 50, 51, 36, 59, 58,
+	148, 0, 0, 0, 0, 0, 0, 37, 38, 53,
+	55, 56, 57, 0, 0, 47, 48, 49, 50, 51,
+	36, 59, 0, 0, 0, 0, 0, 0, 0, 0,
+	54, 60, 52, 55, 56, 57, 0, 0, 0, 0,
+	0, 0, 58, 105, 0, 0, 0, 0, 0, 0,
+	37, 38, 53, 54, 60, 52, 0, 0, 47, 48,
+	49, 50, 51, 36, 59, 58, 30, 0, 0, 0,
+	0, 0, 0, 37, 38, 53, 55, 56, 57, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 54, 60, 52, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 58, 0,
+	0, 0, 0, 0, 0, 0, 37, 38, 53,
 }
-var yyPact = [...]int{
-
-	113, -1000, -8, -1000, 47, 45, -1000, 217, -1, 12,
-	-1000, -1000, 186, 186, -1000, -24, 155, -1000, -1000, -1000,
-	1, 1, 46, 38, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, 82, -1000, 25, 21,
-	-1000, 53, 113, 113, 113, 113, 113, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 113,
-	113, 113, 113, 113, 113, 113, -1000, -1000, 144, -24,
-	197, 1, -1000, 113, 1, 38, 7, -1000, -1000, 35,
-	-1000, 15, 10, -1000, -1000, 45, -1000, -1000, -1000, -14,
-	12, 12, -1000, -1000, -1000, -1000, -1000, 197, -24, -1000,
-	6, -1000, -1000, 34, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, 113, -1000,
+
+var yyPact = [...]int16{
+	250, -32768, -10, 250, -32768, 7, 134, -32768, -32768, -32768,
+	-32768, 145, 132, 87, 86, 81, 86, 86, -32768, 144,
+	-8, 6, 97, 101, -32768, -32768, 461, 461, -32768, -44,
+	438, -32768, -32768, -32768, 15, 15, 78, 41, -32768, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, 189, -32768, 143, 80, 79, 69, -32768, -32768,
+	149, 311, -10, -27, -32768, 372, 63, 372, 42, -32768,
+	140, 18, -32768, 139, 311, 50, 38, 372, 372, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, 372, 372, 372, 372, 372, 372, 372, 372, 372,
+	372, -32768, -32768, 395, -44, 504, 15, -32768, 311, 15,
+	41, 25, -32768, -32768, 46, -32768, -32768, 56, 55, 52,
+	-32768, -32768, -32768, 132, 54, -32768, 311, 87, 92, 311,
+	86, 113, 27, 311, 311, -32768, -32768, -28, 6, 6,
+	97, 97, 97, 97, 101, -32768, -32768, -32768, 504, -44,
+	-32768, -20, -32768, -32768, 26, -32768, -32768, -32768, -32768, -32768,
+	-24, -2, 138, -32768, -32768, 311, -32768, -32768, 311, 110,
+	-32768, -32768, -32768, -32768, -32768, 311, 311, -32, 84, -32768,
+	-32768, -32768, 311, -32768, -11, 311, 137, 108, -32768, -12,
+	-32768, 311, -32768, -32768,
 }
-var yyPgo = [...]int{
 
-	0, 127, 7, 0, 126, 16, 9, 122, 111, 6,
-	8, 5, 19, 80, 110, 3, 1, 106, 105, 2,
-	104, 101, 73, 4, 100, 98, 97, 96, 92, 91,
-	75,
+var yyPgo = [...]uint8{
+	0, 214, 210, 155, 209, 1, 0, 208, 207, 3,
+	206, 150, 6, 191, 190, 189, 16, 15, 188, 186,
+	14, 13, 9, 23, 12, 8, 143, 185, 10, 2,
+	184, 182, 4, 181, 180, 136, 7, 179, 177, 175,
+	174, 173, 158, 157, 156,
 }
-var yyR1 = [...]int{
-
-	0, 1, 2, 2, 3, 4, 4, 5, 5, 6,
-	6, 6, 7, 7, 7, 7, 7, 7, 8, 8,
-	8, 8, 8, 8, 9, 9, 10, 10, 10, 11,
-	11, 11, 11, 11, 12, 12, 12, 13, 14, 14,
-	14, 15, 15, 15, 16, 16, 17, 17, 18, 18,
-	18, 18, 19, 19, 20, 20, 20, 21, 21, 22,
-	22, 23, 24, 24, 25, 25, 25, 25, 26, 26,
-	27, 28, 28, 29, 29, 30, 30, 30, 30,
+
+var yyR1 = [...]int8{
+	0, 1, 1, 2, 2, 3, 3, 4, 4, 5,
+	5, 6, 6, 6, 6, 6, 7, 8, 8, 9,
+	10, 11, 11, 12, 13, 14, 14, 15, 15, 16,
+	16, 17, 17, 17, 18, 18, 18, 18, 18, 18,
+	19, 19, 19, 19, 19, 19, 20, 20, 21, 21,
+	21, 22, 22, 22, 22, 22, 23, 23, 24, 24,
+	24, 25, 25, 25, 26, 27, 27, 27, 28, 28,
+	28, 29, 29, 30, 30, 31, 31, 31, 31, 32,
+	32, 33, 33, 33, 33, 34, 34, 34, 35, 35,
+	36, 37, 37, 38, 38, 38, 38, 38, 39, 39,
+	40, 41, 42, 42, 43, 43, 44, 44, 44, 44,
+	44,
 }
-var yyR2 = [...]int{
-
-	0, 1, 1, 3, 1, 1, 3, 1, 3, 1,
-	3, 3, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 1, 1, 1, 1, 3, 1, 3, 3, 1,
-	3, 3, 3, 3, 1, 2, 2, 1, 1, 2,
-	3, 1, 3, 4, 1, 1, 1, 2, 3, 2,
-	1, 1, 1, 1, 1, 1, 2, 3, 3, 1,
-	2, 3, 1, 2, 1, 1, 1, 1, 3, 2,
-	1, 3, 4, 1, 3, 1, 1, 1, 1,
+
+var yyR2 = [...]int8{
+	0, 1, 2, 2, 3, 7, 8, 2, 4, 1,
+	3, 1, 1, 1, 1, 1, 4, 1, 3, 4,
+	4, 1, 3, 4, 8, 4, 4, 1, 3, 1,
+	3, 1, 3, 3, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 3, 1, 3,
+	3, 1, 3, 3, 3, 3, 1, 3, 1, 3,
+	3, 1, 2, 2, 1, 1, 2, 3, 1, 3,
+	4, 1, 1, 1, 2, 3, 2, 1, 1, 1,
+	1, 1, 1, 1, 2, 3, 3, 3, 1, 2,
+	3, 1, 2, 1, 1, 1, 1, 1, 3, 2,
+	1, 2, 3, 4, 1, 3, 1, 1, 1, 1,
+	1,
 }
-var yyChk = [...]int{
-
-	-1000, -1, -2, -3, -4, -5, -6, -9, -10, -11,
-	-12, -13, 31, 32, -14, -15, 34, -16, -17, -24,
-	-18, -25, 8, 41, 42, -19, -30, -26, -27, -28,
-	-21, -20, 4, 5, 6, 7, 26, 43, 20, 21,
-	33, 25, 30, 9, 10, -7, -8, 14, 15, 16,
-	17, 18, 19, 35, 36, 37, 38, 39, 40, 22,
-	31, 32, 33, 11, 12, 13, -13, -13, 34, -15,
-	34, -22, -23, 28, -22, 23, 26, -19, 8, -2,
-	27, 26, 26, 4, -3, -5, -6, -9, -9, -10,
-	-11, -11, -12, -12, -12, -12, -16, 34, -15, -23,
-	-2, -19, 27, -29, -3, 27, 27, 27, -16, 29,
-	27, 30, -3,
+
+var yyChk = [...]int16{
+	-32768, -1, -5, -2, -6, -3, -15, -7, -10, -13,
+	-14, 41, -16, 27, 28, 30, 33, 34, -17, -20,
+	-21, -22, -23, -24, -25, -26, 52, 53, -27, -28,
+	55, -29, -30, -37, -31, -38, 9, 62, 63, -32,
+	-44, -39, -40, -42, -41, -34, -33, 4, 5, 6,
+	7, 8, 44, 64, 42, 22, 23, 24, 54, 10,
+	43, 48, -5, -3, 49, 11, 9, 12, -8, -9,
+	42, -11, -12, 42, 44, -11, -11, -18, -19, 16,
+	17, 18, 19, 20, 21, 56, 57, 58, 59, 60,
+	61, 25, 52, 53, 54, 13, 14, 15, 36, 37,
+	38, -26, -26, 55, -28, 55, -35, -36, 46, -35,
+	26, 44, -32, 9, -5, 45, 9, 44, 44, 44,
+	4, -6, 49, -16, 44, -17, 39, 48, 9, 39,
+	48, 9, -5, 35, 35, -20, -20, -21, -22, -22,
+	-23, -23, -23, -23, -24, -25, -25, -29, 55, -28,
+	-36, -5, -32, 45, -43, -6, 45, 45, 45, 45,
+	45, -4, 42, -6, -9, 40, -6, -12, 29, 45,
+	-6, -6, -29, 47, 45, 48, 50, 45, 48, 9,
+	-6, -6, 31, -6, -5, 50, 42, -6, 51, -5,
+	9, 32, 51, -6,
 }
-var yyDef = [...]int{
-
-	0, -2, 1, 2, 4, 5, 7, 9, 24, 26,
-	29, 34, 0, 0, 37, 38, 0, 41, 44, 45,
-	46, 62, 54, 0, 50, 51, 64, 65, 66, 67,
-	52, 53, 75, 76, 77, 78, 0, 70, 0, 0,
-	55, 0, 0, 0, 0, 0, 0, 12, 13, 14,
-	15, 16, 17, 18, 19, 20, 21, 22, 23, 0,
-	0, 0, 0, 0, 0, 0, 35, 36, 0, 39,
-	0, 47, 59, 0, 63, 0, 0, 49, 54, 0,
-	69, 0, 0, 56, 3, 6, 8, 10, 11, 25,
-	27, 28, 30, 31, 32, 33, 42, 0, 40, 60,
-	0, 48, 71, 0, 73, 68, 57, 58, 43, 61,
-	72, 0, 74,
+
+var yyDef = [...]int8{
+	0, -2, 1, 0, 9, 0, 11, 12, 13, 14,
+	15, 0, 27, 0, 0, 0, 0, 0, 29, 31,
+	46, 48, 51, 56, 58, 61, 0, 0, 64, 65,
+	0, 68, 71, 72, 73, 91, 81, 0, 77, 78,
+	93, 94, 95, 96, 97, 79, 80, 106, 107, 108,
+	109, 110, 0, 100, 0, 0, 0, 0, 82, 83,
+	0, 0, 2, 0, 3, 0, 0, 0, 0, 17,
+	0, 0, 21, 0, 0, 0, 0, 0, 0, 34,
+	35, 36, 37, 38, 39, 40, 41, 42, 43, 44,
+	45, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 62, 63, 0, 66, 0, 74, 88, 0, 92,
+	0, 0, 76, 81, 0, 99, 101, 0, 0, 0,
+	84, 10, 4, 28, 0, 30, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 32, 33, 47, 49, 50,
+	52, 53, 54, 55, 57, 59, 60, 69, 0, 67,
+	89, 0, 75, 102, 0, 104, 98, 85, 86, 87,
+	0, 0, 0, 16, 18, 0, 20, 22, 0, 0,
+	25, 26, 70, 90, 103, 0, 0, 0, 0, 7,
+	19, 23, 0, 105, 0, 0, 0, 0, 5, 0,
+	8, 0, 6, 24,
 }
-var yyTok1 = [...]int{
 
+var yyTok1 = [...]int8{
 	1,
 }
-var yyTok2 = [...]int{
 
+var yyTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
-	42, 43,
+	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
+	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
+	62, 63, 64,
 }
-var yyTok3 = [...]int{
+
+var yyTok3 = [...]int8{
 	0,
 }
 
@@ -280,7 +393,7 @@ func yyNewParser() yyParser {
 	return &yyParserImpl{}
 }
 
-const yyFlag = -1000
+const yyFlag = -32768
 
 func yyTokname(c int) string {
 	if c >= 1 && c-1 < len(yyToknames) {
@@ -319,9 +432,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -331,13 +444,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -368,30 +481,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -446,7 +559,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -457,8 +570,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -471,7 +584,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -480,18 +593,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -513,10 +626,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -552,7 +665,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -563,16 +676,16 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
@@ -580,229 +693,391 @@ yydefault:
 
 	case 1:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:87
+//line dpath.y:127
 		{
-			parserResult = newSequenceTree(yyDollar[1].args)
+			yylex.(*globLexer).result = newSequenceTree(yyDollar[1].args)
 		}
 	case 2:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line dpath.y:128
+		{
+			yylex.(*globLexer).result = newModuleTree(yyDollar[1].decls, newSequenceTree(yyDollar[2].args))
+		}
+	case 3:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line dpath.y:131
+		{
+			yyVAL.decls = []*FunctionDecl{yyDollar[1].decl}
+		}
+	case 4:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line dpath.y:132
+		{
+			yyVAL.decls = append(yyDollar[1].decls, yyDollar[2].decl)
+		}
+	case 5:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line dpath.y:136
+		{
+			yyVAL.decl = newFunctionDecl(yyDollar[2].str, []string{}, newSequenceTree(yyDollar[6].args))
+		}
+	case 6:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line dpath.y:138
+		{
+			yyVAL.decl = newFunctionDecl(yyDollar[2].str, yyDollar[4].names, newSequenceTree(yyDollar[7].args))
+		}
+	case 7:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line dpath.y:141
+		{
+			yyVAL.names = []string{yyDollar[2].str}
+		}
+	case 8:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line dpath.y:142
+		{
+			yyVAL.names = append(yyDollar[1].names, yyDollar[4].str)
+		}
+	case 9:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:90
+//line dpath.y:145
 		{
 			yyVAL.args = []ParseTree{yyDollar[1].tree}
 		}
-	case 3:
+	case 10:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:91
+//line dpath.y:146
 		{
 			yyVAL.args = append(yyDollar[1].args, yyDollar[3].tree)
 		}
-	case 4:
+	case 11:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:94
+//line dpath.y:149
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 5:
+	case 12:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:97
+//line dpath.y:150
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 6:
+	case 13:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line dpath.y:151
+		{
+			yyVAL.tree = yyDollar[1].tree
+		}
+	case 14:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line dpath.y:152
+		{
+			yyVAL.tree = yyDollar[1].tree
+		}
+	case 15:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line dpath.y:153
+		{
+			yyVAL.tree = yyDollar[1].tree
+		}
+	case 16:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line dpath.y:156
+		{
+			yyVAL.tree = newLetTree(yyDollar[2].bindings, yyDollar[4].tree)
+		}
+	case 17:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line dpath.y:159
+		{
+			yyVAL.bindings = []*VarBinding{yyDollar[1].binding}
+		}
+	case 18:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line dpath.y:160
+		{
+			yyVAL.bindings = append(yyDollar[1].bindings, yyDollar[3].binding)
+		}
+	case 19:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line dpath.y:163
+		{
+			yyVAL.binding = newVarBinding(yyDollar[2].str, yyDollar[4].tree)
+		}
+	case 20:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line dpath.y:166
+		{
+			yyVAL.tree = newForTree(yyDollar[2].bindings, yyDollar[4].tree)
+		}
+	case 21:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line dpath.y:169
+		{
+			yyVAL.bindings = []*VarBinding{yyDollar[1].binding}
+		}
+	case 22:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line dpath.y:170
+		{
+			yyVAL.bindings = append(yyDollar[1].bindings, yyDollar[3].binding)
+		}
+	case 23:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line dpath.y:173
+		{
+			yyVAL.binding = newVarBinding(yyDollar[2].str, yyDollar[4].tree)
+		}
+	case 24:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line dpath.y:177
+		{
+			yyVAL.tree = newIfTree(newSequenceTree(yyDollar[3].args), yyDollar[6].tree, yyDollar[8].tree)
+		}
+	case 25:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line dpath.y:180
+		{
+			yyVAL.tree = newQuantifiedTree("some", yyDollar[2].bindings, yyDollar[4].tree)
+		}
+	case 26:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line dpath.y:181
+		{
+			yyVAL.tree = newQuantifiedTree("every", yyDollar[2].bindings, yyDollar[4].tree)
+		}
+	case 27:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line dpath.y:184
+		{
+			yyVAL.tree = yyDollar[1].tree
+		}
+	case 28:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:98
+//line dpath.y:185
 		{
 			yyVAL.tree = newBinopTree("or", yyDollar[1].tree, yyDollar[3].tree)
 		}
-	case 7:
+	case 29:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:101
+//line dpath.y:188
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 8:
+	case 30:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:102
+//line dpath.y:189
 		{
 			yyVAL.tree = newBinopTree("and", yyDollar[1].tree, yyDollar[3].tree)
 		}
-	case 9:
+	case 31:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:105
+//line dpath.y:192
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 10:
+	case 32:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:106
+//line dpath.y:193
 		{
 			yyVAL.tree = newBinopTree(yyDollar[2].str, yyDollar[1].tree, yyDollar[3].tree)
 		}
-	case 11:
+	case 33:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:107
+//line dpath.y:194
 		{
 			yyVAL.tree = newBinopTree(yyDollar[2].str, yyDollar[1].tree, yyDollar[3].tree)
 		}
-	case 12:
+	case 34:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:110
+//line dpath.y:197
 		{
 			yyVAL.str = "eq"
 		}
-	case 13:
+	case 35:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:111
+//line dpath.y:198
 		{
 			yyVAL.str = "ne"
 		}
-	case 14:
+	case 36:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:112
+//line dpath.y:199
 		{
 			yyVAL.str = "lt"
 		}
-	case 15:
+	case 37:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:113
+//line dpath.y:200
 		{
 			yyVAL.str = "le"
 		}
-	case 16:
+	case 38:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:114
+//line dpath.y:201
 		{
 			yyVAL.str = "gt"
 		}
-	case 17:
+	case 39:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:115
+//line dpath.y:202
 		{
 			yyVAL.str = "ge"
 		}
-	case 18:
+	case 40:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:118
+//line dpath.y:205
 		{
 			yyVAL.str = "="
 		}
-	case 19:
+	case 41:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:119
+//line dpath.y:206
 		{
 			yyVAL.str = "!="
 		}
-	case 20:
+	case 42:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:120
+//line dpath.y:207
 		{
 			yyVAL.str = "<"
 		}
-	case 21:
+	case 43:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:121
+//line dpath.y:208
 		{
 			yyVAL.str = "<="
 		}
-	case 22:
+	case 44:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:122
+//line dpath.y:209
 		{
 			yyVAL.str = ">"
 		}
-	case 23:
+	case 45:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:123
+//line dpath.y:210
 		{
 			yyVAL.str = ">="
 		}
-	case 24:
+	case 46:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:126
+//line dpath.y:213
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 25:
+	case 47:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:127
+//line dpath.y:214
 		{
 			yyVAL.tree = newBinopTree("to", yyDollar[1].tree, yyDollar[3].tree)
 		}
-	case 26:
+	case 48:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:130
+//line dpath.y:217
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 27:
+	case 49:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:131
+//line dpath.y:218
 		{
 			yyVAL.tree = newBinopTree("+", yyDollar[1].tree, yyDollar[3].tree)
 		}
-	case 28:
+	case 50:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:132
+//line dpath.y:219
 		{
 			yyVAL.tree = newBinopTree("-", yyDollar[1].tree, yyDollar[3].tree)
 		}
-	case 29:
+	case 51:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:136
+//line dpath.y:223
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 30:
+	case 52:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:137
+//line dpath.y:224
 		{
 			yyVAL.tree = newBinopTree("*", yyDollar[1].tree, yyDollar[3].tree)
 		}
-	case 31:
+	case 53:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:138
+//line dpath.y:225
 		{
 			yyVAL.tree = newBinopTree("div", yyDollar[1].tree, yyDollar[3].tree)
 		}
-	case 32:
+	case 54:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:139
+//line dpath.y:226
 		{
 			yyVAL.tree = newBinopTree("idiv", yyDollar[1].tree, yyDollar[3].tree)
 		}
-	case 33:
+	case 55:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:140
+//line dpath.y:227
 		{
 			yyVAL.tree = newBinopTree("mod", yyDollar[1].tree, yyDollar[3].tree)
 		}
-	case 34:
+	case 56:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:143
+//line dpath.y:230
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 35:
+	case 57:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line dpath.y:231
+		{
+			yyVAL.tree = newBinopTree("union", yyDollar[1].tree, yyDollar[3].tree)
+		}
+	case 58:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line dpath.y:235
+		{
+			yyVAL.tree = yyDollar[1].tree
+		}
+	case 59:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line dpath.y:236
+		{
+			yyVAL.tree = newBinopTree("intersect", yyDollar[1].tree, yyDollar[3].tree)
+		}
+	case 60:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line dpath.y:237
+		{
+			yyVAL.tree = newBinopTree("except", yyDollar[1].tree, yyDollar[3].tree)
+		}
+	case 61:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line dpath.y:240
+		{
+			yyVAL.tree = yyDollar[1].tree
+		}
+	case 62:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line dpath.y:144
+//line dpath.y:241
 		{
 			yyVAL.tree = newUnopTree("+", yyDollar[2].tree)
 		}
-	case 36:
+	case 63:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line dpath.y:145
+//line dpath.y:242
 		{
 			yyVAL.tree = newUnopTree("-", yyDollar[2].tree)
 		}
-	case 37:
+	case 64:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:148
+//line dpath.y:245
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 38:
+	case 65:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:152
+//line dpath.y:249
 		{
 			if len(yyDollar[1].args) == 1 {
 				yyVAL.tree = yyDollar[1].args[0]
@@ -810,246 +1085,280 @@ yydefault:
 				yyVAL.tree = newPathTree(yyDollar[1].args, false)
 			}
 		}
-	case 39:
+	case 66:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line dpath.y:159
+//line dpath.y:256
 		{
 			yyVAL.tree = newPathTree(yyDollar[2].args, true)
 		}
-	case 40:
+	case 67:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:160
+//line dpath.y:257
 		{
 			yyVAL.tree = newPathTree(append([]ParseTree{nil}, yyDollar[3].args...), true)
 		}
-	case 41:
+	case 68:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:164
+//line dpath.y:261
 		{
 			yyVAL.args = []ParseTree{yyDollar[1].tree}
 		}
-	case 42:
+	case 69:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:165
+//line dpath.y:262
 		{
 			yyVAL.args = append(yyDollar[1].args, yyDollar[3].tree)
 		}
-	case 43:
+	case 70:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line dpath.y:166
+//line dpath.y:263
 		{
 			yyVAL.args = append(yyDollar[1].args, nil, yyDollar[4].tree)
 		}
-	case 44:
+	case 71:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:169
+//line dpath.y:266
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 45:
+	case 72:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:170
+//line dpath.y:267
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 46:
+	case 73:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:173
+//line dpath.y:270
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 47:
+	case 74:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line dpath.y:174
+//line dpath.y:271
 		{
-			yyVAL.tree = newFilteredSequenceTree(yyDollar[1].tree, yyDollar[2].args)
+			yyVAL.tree = newFilteredStepTree(yyDollar[1].tree, yyDollar[2].args)
 		}
-	case 48:
+	case 75:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:177
+//line dpath.y:274
 		{
 			yyVAL.tree = newAxisTree(yyDollar[1].str, yyDollar[3].tree)
 		}
-	case 49:
+	case 76:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line dpath.y:178
+//line dpath.y:275
 		{
 			yyVAL.tree = newAxisTree("attribute", yyDollar[2].tree)
 		}
-	case 50:
+	case 77:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:179
+//line dpath.y:276
 		{
 			yyVAL.tree = newKindTree("..")
 		}
-	case 51:
+	case 78:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:180
+//line dpath.y:277
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 52:
+	case 79:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:183
+//line dpath.y:280
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 53:
+	case 80:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:184
+//line dpath.y:281
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 54:
+	case 81:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:187
+//line dpath.y:284
 		{
 			yyVAL.tree = newNameTree(yyDollar[1].str)
 		}
-	case 55:
+	case 82:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:188
+//line dpath.y:285
 		{
 			yyVAL.tree = newKindTree("*")
 		}
-	case 56:
+	case 83:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line dpath.y:286
+		{
+			yyVAL.tree = newGlobTree(yyDollar[1].str)
+		}
+	case 84:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line dpath.y:189
+//line dpath.y:287
 		{
 			yyVAL.tree = newNameTree(parseStringLiteral(yyDollar[2].str))
 		}
-	case 57:
+	case 85:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:192
+//line dpath.y:290
 		{
 			yyVAL.tree = newKindTree("file")
 		}
-	case 58:
+	case 86:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:193
+//line dpath.y:291
 		{
 			yyVAL.tree = newKindTree("dir")
 		}
-	case 59:
+	case 87:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line dpath.y:292
+		{
+			yyVAL.tree = newKindTree("symlink")
+		}
+	case 88:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:196
+//line dpath.y:295
 		{
 			yyVAL.args = []ParseTree{yyDollar[1].tree}
 		}
-	case 60:
+	case 89:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line dpath.y:197
+//line dpath.y:296
 		{
 			yyVAL.args = append(yyDollar[1].args, yyDollar[2].tree)
 		}
-	case 61:
+	case 90:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:200
+//line dpath.y:299
 		{
 			yyVAL.tree = newSequenceTree(yyDollar[2].args)
 		}
-	case 62:
+	case 91:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:203
+//line dpath.y:302
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 63:
+	case 92:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line dpath.y:204
+//line dpath.y:303
 		{
 			yyVAL.tree = newFilteredSequenceTree(yyDollar[1].tree, yyDollar[2].args)
 		}
-	case 64:
+	case 93:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:207
+//line dpath.y:306
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 65:
+	case 94:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:208
+//line dpath.y:307
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 66:
+	case 95:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:209
+//line dpath.y:308
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 67:
+	case 96:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:210
+//line dpath.y:309
 		{
 			yyVAL.tree = yyDollar[1].tree
 		}
-	case 68:
+	case 97:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line dpath.y:310
+		{
+			yyVAL.tree = yyDollar[1].tree
+		}
+	case 98:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:214
+//line dpath.y:314
 		{
 			yyVAL.tree = newSequenceTree(yyDollar[2].args)
 		}
-	case 69:
+	case 99:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line dpath.y:215
+//line dpath.y:315
 		{
 			yyVAL.tree = newEmptySequenceTree()
 		}
-	case 70:
+	case 100:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:218
+//line dpath.y:318
 		{
 			yyVAL.tree = newContextItemTree()
 		}
-	case 71:
+	case 101:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line dpath.y:321
+		{
+			yyVAL.tree = newVarRefTree(yyDollar[2].str)
+		}
+	case 102:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:221
+//line dpath.y:324
 		{
 			yyVAL.tree = newFunccallTree(yyDollar[1].str, []ParseTree{})
 		}
-	case 72:
+	case 103:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line dpath.y:222
+//line dpath.y:325
 		{
 			yyVAL.tree = newFunccallTree(yyDollar[1].str, yyDollar[3].args)
 		}
-	case 73:
+	case 104:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:225
+//line dpath.y:328
 		{
 			yyVAL.args = []ParseTree{yyDollar[1].tree}
 		}
-	case 74:
+	case 105:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line dpath.y:226
+//line dpath.y:329
 		{
 			yyVAL.args = append(yyDollar[1].args, yyDollar[3].tree)
 		}
-	case 75:
+	case 106:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:229
+//line dpath.y:332
 		{
 			yyVAL.tree = newStringTree(yyDollar[1].str)
 		}
-	case 76:
+	case 107:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:230
+//line dpath.y:333
 		{
 			yyVAL.tree = newIntegerTree(yyDollar[1].str)
 		}
-	case 77:
+	case 108:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:231
+//line dpath.y:334
 		{
 			yyVAL.tree = newDoubleTree(yyDollar[1].str)
 		}
-	case 78:
+	case 109:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line dpath.y:232
+//line dpath.y:335
 		{
 			yyVAL.tree = newDoubleTree(yyDollar[1].str)
 		}
+	case 110:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line dpath.y:337
+		{
+			size, err := newSizeTree(yyDollar[1].str)
+			if err != nil {
+				yylex.(*globLexer).fail(err)
+			}
+			yyVAL.tree = size
+		}
 	}
 	goto yystack /* stack new state and value */
 }